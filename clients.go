@@ -9,6 +9,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 )
 
@@ -22,6 +24,12 @@ func iamClient(ctx context.Context, awsConfig aws.Config, c *Config) *iam.Client
 			})
 			opts.EndpointResolver = iam.EndpointResolverFromURL(c.IamEndpoint) //nolint:staticcheck // The replacement is not documented yet (2023/07/31)
 		}
+		if c.CorrectClockSkew {
+			opts.HTTPSignerV4 = clockskew.NewSigner(opts.HTTPSignerV4, clockSkewTracker(ctx, awsConfig))
+		}
+		if opts.Retryer != nil {
+			opts.Retryer = networkErrorRetryer(opts.Retryer, c)
+		}
 	})
 }
 
@@ -41,5 +49,28 @@ func stsClient(ctx context.Context, awsConfig aws.Config, c *Config) *sts.Client
 			})
 			opts.EndpointResolver = sts.EndpointResolverFromURL(c.StsEndpoint) //nolint:staticcheck // The replacement is not documented yet (2023/07/31)
 		}
+		if c.CorrectClockSkew {
+			opts.HTTPSignerV4 = clockskew.NewSigner(opts.HTTPSignerV4, clockSkewTracker(ctx, awsConfig))
+		}
+		if opts.Retryer != nil {
+			opts.Retryer = networkErrorRetryer(opts.Retryer, c)
+		}
 	})
 }
+
+// clockSkewTracker returns the clock skew tracker of awsConfig or, while the configuration is being resolved, of ctx.
+func clockSkewTracker(ctx context.Context, awsConfig aws.Config) *clockskew.Tracker {
+	if tracker := clockskew.FromConfig(awsConfig); tracker != nil {
+		return tracker
+	}
+	return clockskew.FromContext(ctx)
+}
+
+// networkErrorRetryer wraps retryer to abandon retries of the networking errors configured in c.
+// The retryers of the aws.Config returned by GetAwsConfig already do, see resolveRetryer.
+func networkErrorRetryer(retryer aws.Retryer, c *Config) aws.Retryer {
+	if _, ok := retryer.(*retryDelayRecorder); ok {
+		return retryer
+	}
+	return netretry.NewRetryer(retryer, netretry.NewClassifier(c.MaxNetworkErrorRetries, c.NonRetryableNetworkErrors))
+}
@@ -5,6 +5,7 @@ package awsbase
 
 import (
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
 )
 
 // Config, APNInfo, APNProduct, and AssumeRole are aliased to an internal package to break a dependency cycle
@@ -37,4 +38,22 @@ func EC2MetadataEndpointMode_Values() []string {
 const (
 	HTTPProxyModeLegacy   = config.HTTPProxyModeLegacy
 	HTTPProxyModeSeparate = config.HTTPProxyModeSeparate
+	HTTPProxyModePAC      = config.HTTPProxyModePAC
 )
+
+// NetworkErrorClass is a class of networking error for which retries are abandoned. See Config.NonRetryableNetworkErrors.
+type NetworkErrorClass = netretry.Class
+
+const (
+	NetworkErrorClassNoSuchHost                  = netretry.ClassNoSuchHost
+	NetworkErrorClassConnectionRefused           = netretry.ClassConnectionRefused
+	NetworkErrorClassNetworkUnreachable          = netretry.ClassNetworkUnreachable
+	NetworkErrorClassTimeout                     = netretry.ClassTimeout
+	NetworkErrorClassTLSHandshakeTimeout         = netretry.ClassTLSHandshakeTimeout
+	NetworkErrorClassCertificate                 = netretry.ClassCertificate
+	NetworkErrorClassProxyAuthenticationRequired = netretry.ClassProxyAuthenticationRequired
+)
+
+func NetworkErrorClass_Values() []NetworkErrorClass {
+	return netretry.Classes()
+}
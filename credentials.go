@@ -7,12 +7,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 )
 
@@ -21,13 +23,17 @@ const (
 	configSourceEnvironmentVariable = "envvar"
 )
 
-func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProvider, string, diag.Diagnostics) {
+func getCredentialsProvider(ctx context.Context, c *Config) (_ aws.CredentialsProvider, _ string, diags diag.Diagnostics) {
 	// This function will need to exist for any authentication methods that call STS until the providers use a reasonable default `MaxRetries`.
 	// Otherwise, retryable errors will cause the provider to appear to have frozen.
-	var diags diag.Diagnostics
-
 	logger := logging.RetrieveLogger(ctx)
 
+	// Credential errors caused by clock skew are explained by a warning, whatever the outcome
+	clockSkew := clockskew.FromContext(ctx)
+	defer func() {
+		diags = addClockSkewWarning(diags, c, clockSkew)
+	}()
+
 	loadOptions, err := commonLoadOptions(ctx, c)
 	if err != nil {
 		return nil, "", diags.AddSimpleError(err)
@@ -71,6 +77,7 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 	if err != nil {
 		return nil, "", diags.AddSimpleError(err)
 	}
+	clockskew.AddToConfig(&cfg, clockSkew)
 
 	// This can probably be configured directly in commonLoadOptions() once
 	// https://github.com/aws/aws-sdk-go-v2/pull/1682 is merged
@@ -92,12 +99,15 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 	logger.Debug(ctx, "Retrieving credentials")
 	creds, err := cfg.Credentials.Retrieve(ctx)
 	if err != nil {
+		if pe, ok := asPinningError(err); ok {
+			return nil, "", diags.Append(newTLSPinningError(pe))
+		}
 		if c.Profile != "" && os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
 			err = fmt.Errorf(`A Profile was specified along with the environment variables "AWS_ACCESS_KEY_ID" and "AWS_SECRET_ACCESS_KEY". The Profile is now used instead of the environment variable credentials.
 
 AWS Error: %w`, err)
 		}
-		return nil, "", diags.Append(c.NewNoValidCredentialSourcesError(err))
+		return nil, "", diags.Append(newNoValidCredentialSourcesError(c, err))
 	}
 
 	if len(c.AssumeRole) == 0 {
@@ -116,6 +126,29 @@ AWS Error: %w`, err)
 	return provider, creds.Source, diags
 }
 
+// addClockSkewWarning adds a warning if the clock skew observed in AWS API responses exceeds the threshold, as signing
+// failures caused by a drifting local clock are otherwise reported as obscure STS errors.
+func addClockSkewWarning(diags diag.Diagnostics, c *Config, tracker *clockskew.Tracker) diag.Diagnostics {
+	skew, ok := tracker.Exceeds(c.ClockSkewWarningThreshold)
+	if !ok {
+		return diags
+	}
+
+	direction := "behind"
+	if skew < 0 {
+		direction = "ahead of"
+	}
+
+	detail := fmt.Sprintf("The local clock is %s %s the clock of AWS, based on the Date header of AWS API responses. ", skew.Abs().Round(time.Second), direction) +
+		`AWS rejects requests whose signatures are too far from the current time, with errors such as "RequestTimeTooSkewed" or "SignatureDoesNotMatch". ` +
+		"Synchronize the local clock, for example using NTP."
+	if c.CorrectClockSkew {
+		detail += " The observed skew is applied to request signatures."
+	}
+
+	return diags.AddWarning("Clock skew detected", detail)
+}
+
 func webIdentityCredentialsProvider(ctx context.Context, awsConfig aws.Config, c *Config) (aws.CredentialsProvider, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
@@ -147,7 +180,10 @@ func webIdentityCredentialsProvider(ctx context.Context, awsConfig aws.Config, c
 	})
 
 	if _, err := appCreds.Retrieve(ctx); err != nil {
-		return nil, diags.Append(c.NewCannotAssumeRoleWithWebIdentityError(err))
+		if pe, ok := asPinningError(err); ok {
+			return nil, diags.Append(newTLSPinningError(pe))
+		}
+		return nil, diags.Append(newCannotAssumeRoleWithWebIdentityError(*ar, err))
 	}
 	return aws.NewCredentialsCache(appCreds), diags
 }
@@ -218,6 +254,9 @@ func assumeRoleCredentialsProvider(ctx context.Context, awsConfig aws.Config, c
 		})
 		_, err := appCreds.Retrieve(ctx)
 		if err != nil {
+			if pe, ok := asPinningError(err); ok {
+				return nil, diags.Append(newTLSPinningError(pe))
+			}
 			return nil, diags.Append(newCannotAssumeRoleError(ar, err))
 		}
 		creds = aws.NewCredentialsCache(appCreds)
@@ -6,12 +6,17 @@ package awsbase
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
 	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
 )
@@ -206,3 +211,66 @@ func testCredentialsProviderWrappedWithCache(creds aws.CredentialsProvider, t *t
 func sharedConfigCredentialsSource(filename string) string {
 	return fmt.Sprintf(sharedConfigCredentialsProvider+": %s", filename)
 }
+
+func TestAddClockSkewWarning(t *testing.T) {
+	tracker := &clockskew.Tracker{}
+
+	// The Date header has a resolution of one second
+	now := time.Now().Truncate(time.Second)
+	observe := func(skew time.Duration) {
+		tracker.Observe(&http.Response{
+			Header: http.Header{"Date": []string{now.Add(skew).UTC().Format(http.TimeFormat)}},
+		}, now, now)
+	}
+
+	testCases := map[string]struct {
+		config         Config
+		skew           time.Duration
+		expectedDetail string
+	}{
+		"below default threshold": {
+			skew: time.Minute,
+		},
+		"behind": {
+			skew:           10 * time.Minute,
+			expectedDetail: "The local clock is 10m0s behind the clock of AWS",
+		},
+		"ahead": {
+			skew:           -10 * time.Minute,
+			expectedDetail: "The local clock is 10m0s ahead of the clock of AWS",
+		},
+		"custom threshold": {
+			config:         Config{ClockSkewWarningThreshold: 30 * time.Second},
+			skew:           time.Minute,
+			expectedDetail: "The local clock is 1m0s behind the clock of AWS",
+		},
+		"correction": {
+			config:         Config{CorrectClockSkew: true},
+			skew:           10 * time.Minute,
+			expectedDetail: "The observed skew is applied to request signatures.",
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			observe(testcase.skew)
+
+			var diags diag.Diagnostics
+			diags = addClockSkewWarning(diags, &testcase.config, tracker)
+
+			if testcase.expectedDetail == "" {
+				if a := diags.Count(); a != 0 {
+					t.Fatalf("expected no diagnostics, got %d", a)
+				}
+				return
+			}
+
+			if a, e := diags.WarningsCount(), 1; a != e {
+				t.Fatalf("expected %d warning, got %d", e, a)
+			}
+			if detail := diags[0].Detail(); !strings.Contains(detail, testcase.expectedDetail) {
+				t.Errorf("expected detail to contain %q, got %q", testcase.expectedDetail, detail)
+			}
+		})
+	}
+}
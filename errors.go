@@ -5,15 +5,110 @@ package awsbase
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/aws/smithy-go"
 	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
 )
 
+// assumeRoleFailure is the cause of an AssumeRole or AssumeRoleWithWebIdentity failure, identified by its error code.
+type assumeRoleFailure int
+
+const (
+	assumeRoleFailureUnknown assumeRoleFailure = iota
+	assumeRoleFailureAccessDenied
+	assumeRoleFailureInvalidCredentials
+	assumeRoleFailureRegionDisabled
+	assumeRoleFailurePackedPolicyTooLarge
+	assumeRoleFailureMalformedPolicy
+	assumeRoleFailureWebIdentityToken
+)
+
+// assumeRoleFailures maps STS and IAM error codes to the cause of the failure
+var assumeRoleFailures = map[string]assumeRoleFailure{
+	"AccessDenied":            assumeRoleFailureAccessDenied,
+	"ExpiredToken":            assumeRoleFailureInvalidCredentials,
+	"InvalidClientTokenId":    assumeRoleFailureInvalidCredentials,
+	"RegionDisabledException": assumeRoleFailureRegionDisabled,
+	"PackedPolicyTooLarge":    assumeRoleFailurePackedPolicyTooLarge,
+	"MalformedPolicyDocument": assumeRoleFailureMalformedPolicy,
+	"ExpiredTokenException":   assumeRoleFailureWebIdentityToken,
+	"IDPCommunicationError":   assumeRoleFailureWebIdentityToken,
+	"IDPRejectedClaim":        assumeRoleFailureWebIdentityToken,
+	"InvalidIdentityToken":    assumeRoleFailureWebIdentityToken,
+}
+
+func classifyAssumeRoleError(err error) assumeRoleFailure {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		return assumeRoleFailures[apiErr.ErrorCode()]
+	}
+	return assumeRoleFailureUnknown
+}
+
+// remediation returns the causes of the failure and how to resolve them.
+func (f assumeRoleFailure) remediation(webIdentity bool) string {
+	switch f {
+	case assumeRoleFailureAccessDenied:
+		if webIdentity {
+			return `The role's trust policy does not allow the web identity to assume the role. Check that:
+  * The trust policy allows "sts:AssumeRoleWithWebIdentity" for the OIDC identity provider
+  * The trust policy conditions match the audience ("aud") and subject ("sub") claims of the token
+`
+		}
+		return `The credentials are not allowed to assume the role. Check that:
+  * The role's trust policy allows the calling principal to perform "sts:AssumeRole"
+  * The trust policy conditions, such as "sts:ExternalId" or "sts:SourceIdentity", match the configured values
+  * The calling principal's IAM policies allow "sts:AssumeRole" on the role
+`
+	case assumeRoleFailureInvalidCredentials:
+		return `The credentials used to assume the role are expired or invalid. Check that:
+  * Temporary credentials, such as those from an SSO session or a previous role assumption, have been refreshed
+  * The access key exists and is active
+  * The credentials belong to the expected AWS partition
+`
+	case assumeRoleFailureRegionDisabled:
+		return `AWS STS is not activated in the region used to assume the role.
+Activate STS for the region in the account settings of the IAM console, or configure a different STS region or endpoint.
+`
+	case assumeRoleFailurePackedPolicyTooLarge:
+		return `The session policy, policy ARNs and session tags exceed the size limit for AssumeRole.
+Reduce the size of the session policy, the number of policy ARNs, or the number and length of session tags.
+`
+	case assumeRoleFailureMalformedPolicy:
+		return `The session policy is not a valid IAM policy document.
+Check that the session policy is valid JSON and uses valid IAM policy grammar.
+`
+	case assumeRoleFailureWebIdentityToken:
+		return `The web identity token was not accepted. Check that:
+  * The token has not expired, and the token file is refreshed by the identity provider
+  * The token was issued by the OIDC identity provider configured in IAM, with a matching audience
+  * The identity provider is reachable by AWS, and its certificate thumbprint in IAM is current
+`
+	}
+
+	if webIdentity {
+		return `There are a number of possible causes of this - the most common are:
+  * The web identity token used in order to assume the role is invalid
+  * The web identity token does not have appropriate permission to assume the role
+  * The role ARN is not valid
+`
+	}
+	return `There are a number of possible causes of this - the most common are:
+  * The credentials used in order to assume the role are invalid
+  * The credentials do not have appropriate permission to assume the role
+  * The role ARN is not valid
+`
+}
+
 // cannotAssumeRoleError occurs when AssumeRole cannot complete.
 type cannotAssumeRoleError struct {
-	ar  config.AssumeRole
-	err error
+	ar      config.AssumeRole
+	err     error
+	failure assumeRoleFailure
+
+	requestID, extendedRequestID string
 }
 
 func (e cannotAssumeRoleError) Severity() diag.Severity {
@@ -27,13 +122,9 @@ func (e cannotAssumeRoleError) Summary() string {
 func (e cannotAssumeRoleError) Detail() string {
 	return fmt.Sprintf(`IAM Role (%s) cannot be assumed.
 
-There are a number of possible causes of this - the most common are:
-  * The credentials used in order to assume the role are invalid
-  * The credentials do not have appropriate permission to assume the role
-  * The role ARN is not valid
-
+%s
 Error: %s
-`, e.ar.RoleARN, e.err)
+%s`, e.ar.RoleARN, e.failure.remediation(false), e.err, config.RequestIDDetail(e.requestID, e.extendedRequestID))
 }
 
 func (e cannotAssumeRoleError) Equal(other diag.Diagnostic) bool {
@@ -50,9 +141,14 @@ func (e cannotAssumeRoleError) Err() error {
 }
 
 func newCannotAssumeRoleError(ar AssumeRole, err error) cannotAssumeRoleError {
+	requestID, extendedRequestID := config.RequestIDs(err)
+
 	return cannotAssumeRoleError{
-		ar:  ar,
-		err: err,
+		ar:                ar,
+		err:               err,
+		failure:           classifyAssumeRoleError(err),
+		requestID:         requestID,
+		extendedRequestID: extendedRequestID,
 	}
 }
 
@@ -64,6 +160,127 @@ func IsCannotAssumeRoleError(diag diag.Diagnostic) bool {
 	return ok
 }
 
+// CannotAssumeRoleWithWebIdentityError occurs when AssumeRoleWithWebIdentity cannot complete.
+type CannotAssumeRoleWithWebIdentityError = config.CannotAssumeRoleWithWebIdentityError
+
+// cannotAssumeRoleWithWebIdentityError occurs when AssumeRoleWithWebIdentity cannot complete.
+// Unlike CannotAssumeRoleWithWebIdentityError, it lists only the causes matching the error code.
+type cannotAssumeRoleWithWebIdentityError struct {
+	ar      config.AssumeRoleWithWebIdentity
+	err     error
+	failure assumeRoleFailure
+
+	requestID, extendedRequestID string
+}
+
+func (e cannotAssumeRoleWithWebIdentityError) Severity() diag.Severity {
+	return diag.SeverityError
+}
+
+func (e cannotAssumeRoleWithWebIdentityError) Summary() string {
+	return "Cannot assume IAM Role with web identity"
+}
+
+func (e cannotAssumeRoleWithWebIdentityError) Detail() string {
+	return fmt.Sprintf(`IAM Role (%s) cannot be assumed with web identity token.
+
+%s
+Error: %s
+%s`, e.ar.RoleARN, e.failure.remediation(true), e.err, config.RequestIDDetail(e.requestID, e.extendedRequestID))
+}
+
+func (e cannotAssumeRoleWithWebIdentityError) Equal(other diag.Diagnostic) bool {
+	ed, ok := other.(cannotAssumeRoleWithWebIdentityError)
+	if !ok {
+		return false
+	}
+
+	return ed.Summary() == e.Summary() && ed.Detail() == e.Detail()
+}
+
+func (e cannotAssumeRoleWithWebIdentityError) Err() error {
+	return e.err
+}
+
+func newCannotAssumeRoleWithWebIdentityError(ar AssumeRoleWithWebIdentity, err error) cannotAssumeRoleWithWebIdentityError {
+	requestID, extendedRequestID := config.RequestIDs(err)
+
+	return cannotAssumeRoleWithWebIdentityError{
+		ar:                ar,
+		err:               err,
+		failure:           classifyAssumeRoleError(err),
+		requestID:         requestID,
+		extendedRequestID: extendedRequestID,
+	}
+}
+
+var _ diag.DiagnosticWithErr = cannotAssumeRoleWithWebIdentityError{}
+
+// IsCannotAssumeRoleWithWebIdentityError returns true if the diagnostic is a CannotAssumeRoleWithWebIdentityError.
+func IsCannotAssumeRoleWithWebIdentityError(diag diag.Diagnostic) bool {
+	switch diag.(type) {
+	case cannotAssumeRoleWithWebIdentityError, CannotAssumeRoleWithWebIdentityError:
+		return true
+	default:
+		return false
+	}
+}
+
+// assumeRoleFailureOf returns the cause of an AssumeRole or AssumeRoleWithWebIdentity diagnostic.
+func assumeRoleFailureOf(d diag.Diagnostic) (assumeRoleFailure, bool) {
+	switch e := d.(type) {
+	case cannotAssumeRoleError:
+		return e.failure, true
+	case cannotAssumeRoleWithWebIdentityError:
+		return e.failure, true
+	case CannotAssumeRoleWithWebIdentityError:
+		return classifyAssumeRoleError(e.Err()), true
+	default:
+		return assumeRoleFailureUnknown, false
+	}
+}
+
+func isAssumeRoleFailure(d diag.Diagnostic, failure assumeRoleFailure) bool {
+	f, ok := assumeRoleFailureOf(d)
+	return ok && f == failure
+}
+
+// IsAssumeRoleAccessDeniedError returns true if the diagnostic is an assume role error caused by the role's trust
+// policy or the caller's permissions.
+func IsAssumeRoleAccessDeniedError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailureAccessDenied)
+}
+
+// IsAssumeRoleInvalidCredentialsError returns true if the diagnostic is an assume role error caused by expired or
+// invalid credentials, such as "ExpiredToken" or "InvalidClientTokenId".
+func IsAssumeRoleInvalidCredentialsError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailureInvalidCredentials)
+}
+
+// IsAssumeRoleRegionDisabledError returns true if the diagnostic is an assume role error caused by STS not being
+// activated in the region.
+func IsAssumeRoleRegionDisabledError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailureRegionDisabled)
+}
+
+// IsAssumeRolePackedPolicyTooLargeError returns true if the diagnostic is an assume role error caused by the session
+// policy and tags exceeding the size limit.
+func IsAssumeRolePackedPolicyTooLargeError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailurePackedPolicyTooLarge)
+}
+
+// IsAssumeRoleMalformedPolicyError returns true if the diagnostic is an assume role error caused by an invalid session
+// policy.
+func IsAssumeRoleMalformedPolicyError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailureMalformedPolicy)
+}
+
+// IsWebIdentityTokenError returns true if the diagnostic is an assume role with web identity error caused by the web
+// identity token or the identity provider, such as "InvalidIdentityToken" or "IDPCommunicationError".
+func IsWebIdentityTokenError(diag diag.Diagnostic) bool {
+	return isAssumeRoleFailure(diag, assumeRoleFailureWebIdentityToken)
+}
+
 // NoValidCredentialSourcesError occurs when all credential lookup methods have been exhausted without results.
 type NoValidCredentialSourcesError = config.NoValidCredentialSourcesError
 
@@ -73,6 +290,12 @@ func IsNoValidCredentialSourcesError(diag diag.Diagnostic) bool {
 	return ok
 }
 
+// newNoValidCredentialSourcesError returns a NoValidCredentialSourcesError. The AWS request IDs of a failed API call
+// are appended to the error, as the diagnostic only includes the error.
+func newNoValidCredentialSourcesError(c *Config, err error) NoValidCredentialSourcesError {
+	return c.NewNoValidCredentialSourcesError(config.WithRequestIDs(err))
+}
+
 // ContainsNoValidCredentialSourcesError returns true if the diagnostics contains a NoValidCredentialSourcesError type.
 func ContainsNoValidCredentialSourcesError(diags diag.Diagnostics) bool {
 	for _, diag := range diags {
@@ -82,3 +305,69 @@ func ContainsNoValidCredentialSourcesError(diags diag.Diagnostics) bool {
 	}
 	return false
 }
+
+// tlsPinningError occurs when a server's TLS certificate does not match the public keys pinned for its hostname.
+type tlsPinningError struct {
+	err *config.PinningError
+}
+
+func (e tlsPinningError) Severity() diag.Severity {
+	return diag.SeverityError
+}
+
+func (e tlsPinningError) Summary() string {
+	return "TLS certificate pinning failed"
+}
+
+func (e tlsPinningError) Detail() string {
+	return fmt.Sprintf(`The TLS certificate presented by %q does not match any of the public keys pinned for that host.
+
+This may indicate that traffic is being intercepted, or that the endpoint's certificate has been rotated
+and the pinned public keys need to be updated.
+
+Presented public key hashes:
+  * %s
+`, e.err.Host, strings.Join(e.err.Hashes, "\n  * "))
+}
+
+func (e tlsPinningError) Equal(other diag.Diagnostic) bool {
+	ed, ok := other.(tlsPinningError)
+	if !ok {
+		return false
+	}
+
+	return ed.Summary() == e.Summary() && ed.Detail() == e.Detail()
+}
+
+func (e tlsPinningError) Err() error {
+	return e.err
+}
+
+// asPinningError returns the TLS certificate pinning failure that caused err, if any.
+func asPinningError(err error) (*config.PinningError, bool) {
+	return config.AsPinningError(err)
+}
+
+func newTLSPinningError(err *config.PinningError) tlsPinningError {
+	return tlsPinningError{
+		err: err,
+	}
+}
+
+var _ diag.DiagnosticWithErr = tlsPinningError{}
+
+// IsTLSPinningError returns true if the diagnostic is a TLS certificate pinning error.
+func IsTLSPinningError(diag diag.Diagnostic) bool {
+	_, ok := diag.(tlsPinningError)
+	return ok
+}
+
+// ContainsTLSPinningError returns true if the diagnostics contains a TLS certificate pinning error.
+func ContainsTLSPinningError(diags diag.Diagnostics) bool {
+	for _, diag := range diags {
+		if IsTLSPinningError(diag) {
+			return true
+		}
+	}
+	return false
+}
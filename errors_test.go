@@ -4,9 +4,20 @@
 package awsbase
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
 )
 
 func TestIsCannotAssumeRoleError(t *testing.T) {
@@ -27,6 +38,10 @@ func TestIsCannotAssumeRoleError(t *testing.T) {
 			Diag:     cannotAssumeRoleError{},
 			Expected: true,
 		},
+		{
+			Name: "Top-level CannotAssumeRoleWithWebIdentityError",
+			Diag: CannotAssumeRoleWithWebIdentityError{},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -42,6 +57,132 @@ func TestIsCannotAssumeRoleError(t *testing.T) {
 	}
 }
 
+func TestAssumeRoleFailures(t *testing.T) {
+	type isFunc func(diag.Diagnostic) bool
+
+	isFuncs := map[string]isFunc{
+		"IsAssumeRoleAccessDeniedError":         IsAssumeRoleAccessDeniedError,
+		"IsAssumeRoleInvalidCredentialsError":   IsAssumeRoleInvalidCredentialsError,
+		"IsAssumeRoleRegionDisabledError":       IsAssumeRoleRegionDisabledError,
+		"IsAssumeRolePackedPolicyTooLargeError": IsAssumeRolePackedPolicyTooLargeError,
+		"IsAssumeRoleMalformedPolicyError":      IsAssumeRoleMalformedPolicyError,
+		"IsWebIdentityTokenError":               IsWebIdentityTokenError,
+	}
+
+	testCases := map[string]struct {
+		code           string
+		webIdentity    bool
+		expectedIs     string
+		expectedDetail string
+	}{
+		"AccessDenied": {
+			code:           "AccessDenied",
+			expectedIs:     "IsAssumeRoleAccessDeniedError",
+			expectedDetail: `The role's trust policy allows the calling principal to perform "sts:AssumeRole"`,
+		},
+		"AccessDenied web identity": {
+			code:           "AccessDenied",
+			webIdentity:    true,
+			expectedIs:     "IsAssumeRoleAccessDeniedError",
+			expectedDetail: `The trust policy allows "sts:AssumeRoleWithWebIdentity" for the OIDC identity provider`,
+		},
+		"ExpiredToken": {
+			code:           "ExpiredToken",
+			expectedIs:     "IsAssumeRoleInvalidCredentialsError",
+			expectedDetail: "The credentials used to assume the role are expired or invalid.",
+		},
+		"InvalidClientTokenId": {
+			code:           "InvalidClientTokenId",
+			expectedIs:     "IsAssumeRoleInvalidCredentialsError",
+			expectedDetail: "The access key exists and is active",
+		},
+		"RegionDisabledException": {
+			code:           "RegionDisabledException",
+			expectedIs:     "IsAssumeRoleRegionDisabledError",
+			expectedDetail: "AWS STS is not activated in the region used to assume the role.",
+		},
+		"PackedPolicyTooLarge": {
+			code:           "PackedPolicyTooLarge",
+			expectedIs:     "IsAssumeRolePackedPolicyTooLargeError",
+			expectedDetail: "exceed the size limit for AssumeRole",
+		},
+		"MalformedPolicyDocument": {
+			code:           "MalformedPolicyDocument",
+			expectedIs:     "IsAssumeRoleMalformedPolicyError",
+			expectedDetail: "The session policy is not a valid IAM policy document.",
+		},
+		"IDPCommunicationError": {
+			code:           "IDPCommunicationError",
+			webIdentity:    true,
+			expectedIs:     "IsWebIdentityTokenError",
+			expectedDetail: "The identity provider is reachable by AWS",
+		},
+		"InvalidIdentityToken": {
+			code:           "InvalidIdentityToken",
+			webIdentity:    true,
+			expectedIs:     "IsWebIdentityTokenError",
+			expectedDetail: "The web identity token was not accepted.",
+		},
+		"unknown": {
+			code:           "ValidationError",
+			expectedDetail: "The credentials do not have appropriate permission to assume the role",
+		},
+		"unknown web identity": {
+			code:           "ValidationError",
+			webIdentity:    true,
+			expectedDetail: "The web identity token does not have appropriate permission to assume the role",
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := &smithy.OperationError{
+				ServiceID:     "STS",
+				OperationName: "AssumeRole",
+				Err: &smithy.GenericAPIError{
+					Code:    testcase.code,
+					Message: "test",
+				},
+			}
+
+			var d diag.Diagnostic
+			if testcase.webIdentity {
+				d = newCannotAssumeRoleWithWebIdentityError(AssumeRoleWithWebIdentity{RoleARN: "arn:aws:iam::555555555555:role/role"}, err)
+				if !IsCannotAssumeRoleWithWebIdentityError(d) {
+					t.Error("expected IsCannotAssumeRoleWithWebIdentityError")
+				}
+				var apiErr *smithy.GenericAPIError
+				if !errors.As(d.(diag.DiagnosticWithErr).Err(), &apiErr) {
+					t.Error("expected error to wrap the API error")
+				}
+			} else {
+				d = newCannotAssumeRoleError(AssumeRole{RoleARN: "arn:aws:iam::555555555555:role/role"}, err)
+				if !IsCannotAssumeRoleError(d) {
+					t.Error("expected IsCannotAssumeRoleError")
+				}
+			}
+
+			for name, f := range isFuncs {
+				if a, e := f(d), name == testcase.expectedIs; a != e {
+					t.Errorf("expected %s to return %t, got %t", name, e, a)
+				}
+			}
+
+			detail := d.Detail()
+			if !strings.Contains(detail, testcase.expectedDetail) {
+				t.Errorf("expected detail to contain %q, got %q", testcase.expectedDetail, detail)
+			}
+			if e := "Error: " + err.Error(); !strings.Contains(detail, e) {
+				t.Errorf("expected detail to contain %q, got %q", e, detail)
+			}
+			// Only the causes matching a known error code are listed
+			if e := "There are a number of possible causes"; testcase.expectedIs != "" && strings.Contains(detail, e) {
+				t.Errorf("expected detail not to contain %q, got %q", e, detail)
+			}
+		})
+	}
+}
+
 func TestIsNoValidCredentialSourcesError(t *testing.T) {
 	testCases := []struct {
 		Name     string
@@ -74,3 +215,114 @@ func TestIsNoValidCredentialSourcesError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsTLSPinningError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Diag     diag.Diagnostic
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+		},
+		{
+			Name: "Top-level CannotAssumeRoleError",
+			Diag: cannotAssumeRoleError{},
+		},
+		{
+			Name:     "Top-level TLSPinningError",
+			Diag:     newTLSPinningError(&config.PinningError{Host: "sts.amazonaws.com"}),
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := IsTLSPinningError(testCase.Diag)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestCannotAssumeRoleError_requestIDs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("X-Amzn-Requestid", "01234567-89ab-cdef-0123-456789abcdef")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>AccessDenied</Code>
+    <Message>not authorized to perform: sts:AssumeRole</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`))
+	}))
+	defer ts.Close()
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+
+	_, err := client.AssumeRole(context.Background(), &sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws:iam::555555555555:role/role"),
+		RoleSessionName: aws.String("session"),
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	detail := newCannotAssumeRoleError(AssumeRole{RoleARN: "arn:aws:iam::555555555555:role/role"}, err).Detail()
+
+	if e := "AWS Request ID: 01234567-89ab-cdef-0123-456789abcdef\n"; !strings.Contains(detail, e) {
+		t.Errorf("expected detail to contain %q, got %q", e, detail)
+	}
+	if e := "AWS Extended Request ID"; strings.Contains(detail, e) {
+		t.Errorf("expected detail not to contain %q, got %q", e, detail)
+	}
+}
+
+func TestNoValidCredentialSourcesError_requestIDs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "4442587FB7D0A2F9")
+		w.Header().Set("X-Amz-Id-2", "vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw=")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		UsePathStyle: true,
+	})
+
+	_, err := client.HeadBucket(context.Background(), &s3.HeadBucketInput{
+		Bucket: aws.String("bucket"),
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	d := newNoValidCredentialSourcesError(&Config{}, err)
+
+	for _, e := range []string{
+		"AWS Request ID: 4442587FB7D0A2F9\n",
+		"AWS Extended Request ID: vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw=\n",
+	} {
+		if detail := d.Detail(); !strings.Contains(detail, e) {
+			t.Errorf("expected detail to contain %q, got %q", e, detail)
+		}
+	}
+	// The original error is still available
+	if !strings.Contains(d.Err().Error(), "StatusCode: 403") {
+		t.Errorf("expected wrapped error, got %q", d.Err())
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package circuitbreaker stops connection attempts to endpoints that have repeatedly failed at the network level.
+//
+// A breaker is closed while an endpoint is healthy. After a number of consecutive network failures it opens,
+// and connection attempts fail immediately with an *OpenError. Once the cooldown has passed it is half-open:
+// a single attempt is let through, which closes the breaker if it succeeds and re-opens it if it fails.
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCooldown = 30 * time.Second
+)
+
+// Breaker tracks the state of each endpoint independently. It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointState
+}
+
+type endpointState struct {
+	failures int
+	openedAt time.Time
+
+	// probing is set while the single half-open attempt is in progress
+	probing  bool
+	probedAt time.Time
+}
+
+// New returns a Breaker that opens after threshold consecutive failures and half-opens after cooldown.
+// If cooldown is not positive, DefaultCooldown is used.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		endpoints: make(map[string]*endpointState),
+	}
+}
+
+type registryKey struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[registryKey]*Breaker)
+)
+
+// Shared returns a process-wide Breaker for the given settings, so that the AWS SDK for Go v1 and v2 clients,
+// and every client created with the same settings, share the state of each endpoint.
+func Shared(threshold int, cooldown time.Duration) *Breaker {
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	key := registryKey{threshold: threshold, cooldown: cooldown}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	b, ok := registry[key]
+	if !ok {
+		b = New(threshold, cooldown)
+		registry[key] = b
+	}
+	return b
+}
+
+// Allow returns an *OpenError if the breaker for endpoint is open.
+// When the cooldown has passed, the first caller is allowed through as a probe and must report the result
+// with Success or Failure, or with Abandon if the result is unknown. A probe that does not report its result
+// within another cooldown is abandoned.
+func (b *Breaker) Allow(endpoint string) (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.endpoints[endpoint]
+	if !ok || state.failures < b.threshold {
+		return false, nil
+	}
+
+	now := b.now()
+	if state.probing && !now.Before(state.probedAt.Add(b.cooldown)) {
+		state.probing = false
+	}
+
+	retryAt := state.openedAt.Add(b.cooldown)
+	if !state.probing && !now.Before(retryAt) {
+		state.probing = true
+		state.probedAt = now
+		return true, nil
+	}
+
+	return false, &OpenError{
+		Endpoint: endpoint,
+		Failures: state.failures,
+		RetryAt:  retryAt,
+	}
+}
+
+// Success closes the breaker for endpoint.
+func (b *Breaker) Success(endpoint string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.endpoints, endpoint)
+}
+
+// Failure records a network failure for endpoint and reports whether the breaker opened as a result,
+// either for the first time or because a half-open probe failed.
+func (b *Breaker) Failure(endpoint string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.endpoints[endpoint]
+	if !ok {
+		state = &endpointState{}
+		b.endpoints[endpoint] = state
+	}
+
+	state.failures++
+	if state.failures < b.threshold {
+		return false
+	}
+
+	probe := state.probing
+	state.probing = false
+	if state.failures == b.threshold || probe {
+		state.openedAt = b.now()
+		return true
+	}
+	return false
+}
+
+// Abandon releases the half-open probe for endpoint when its result is unknown, e.g. because it was cancelled,
+// so that another probe can be made.
+func (b *Breaker) Abandon(endpoint string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state, ok := b.endpoints[endpoint]; ok {
+		state.probing = false
+	}
+}
+
+// OpenError is returned instead of attempting a connection to an endpoint whose breaker is open.
+type OpenError struct {
+	Endpoint string
+	Failures int
+	RetryAt  time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s after %d consecutive network failures, not attempting connection until %s",
+		e.Endpoint, e.Failures, e.RetryAt.Format(time.RFC3339))
+}
+
+// RetryableError prevents the AWS SDK for Go v2 from retrying the request.
+func (e *OpenError) RetryableError() bool {
+	return false
+}
+
+// Temporary prevents the AWS SDK for Go v1 from retrying the request.
+func (e *OpenError) Temporary() bool {
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const endpoint = "sts.amazonaws.com:443"
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, time.March, 13, 14, 30, 0, 0, time.UTC)
+	b := New(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		expectAllowed(t, b, false)
+		if b.Failure(endpoint) {
+			t.Fatalf("failure %d: expected breaker to remain closed", i+1)
+		}
+	}
+
+	expectAllowed(t, b, false)
+	if !b.Failure(endpoint) {
+		t.Fatal("expected breaker to open")
+	}
+
+	err := expectOpen(t, b)
+	if a, e := err.Failures, 3; a != e {
+		t.Errorf("expected %d failures, got %d", e, a)
+	}
+	if a, e := err.RetryAt, now.Add(time.Minute); !a.Equal(e) {
+		t.Errorf("expected retry at %s, got %s", e, a)
+	}
+
+	// Other endpoints are unaffected
+	if _, err := b.Allow("iam.amazonaws.com:443"); err != nil {
+		t.Errorf("unexpected error for other endpoint: %s", err)
+	}
+
+	// Half-open: a single probe is allowed
+	now = now.Add(time.Minute)
+	expectAllowed(t, b, true)
+	expectOpen(t, b)
+
+	// A failed probe re-opens the breaker for another cooldown
+	if !b.Failure(endpoint) {
+		t.Fatal("expected breaker to re-open after failed probe")
+	}
+	expectOpen(t, b)
+
+	// An abandoned probe allows another probe
+	now = now.Add(time.Minute)
+	expectAllowed(t, b, true)
+	b.Abandon(endpoint)
+	expectAllowed(t, b, true)
+
+	// A successful probe closes the breaker
+	b.Success(endpoint)
+	expectAllowed(t, b, false)
+	if b.Failure(endpoint) {
+		t.Error("expected failure count to be reset")
+	}
+}
+
+func TestBreaker_successResets(t *testing.T) {
+	b := New(2, time.Minute)
+
+	b.Failure(endpoint)
+	b.Success(endpoint)
+	if b.Failure(endpoint) {
+		t.Error("expected breaker to remain closed after non-consecutive failures")
+	}
+}
+
+func TestBreaker_staleProbe(t *testing.T) {
+	now := time.Date(2024, time.March, 13, 14, 30, 0, 0, time.UTC)
+	b := New(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure(endpoint)
+
+	now = now.Add(time.Minute)
+	expectAllowed(t, b, true)
+	expectOpen(t, b)
+
+	// A probe that never reports its result is abandoned after another cooldown
+	now = now.Add(time.Minute)
+	expectAllowed(t, b, true)
+}
+
+func TestShared(t *testing.T) {
+	if Shared(5, 0) != Shared(5, DefaultCooldown) {
+		t.Error("expected the same breaker for equivalent settings")
+	}
+	if Shared(5, time.Minute) == Shared(5, DefaultCooldown) {
+		t.Error("expected different breakers for different settings")
+	}
+}
+
+func TestOpenError_notRetryable(t *testing.T) {
+	var err error = &OpenError{Endpoint: endpoint}
+
+	var retryable interface{ RetryableError() bool }
+	if !errors.As(err, &retryable) || retryable.RetryableError() {
+		t.Error("expected OpenError to not be retryable")
+	}
+
+	var temporary interface{ Temporary() bool }
+	if !errors.As(err, &temporary) || temporary.Temporary() {
+		t.Error("expected OpenError to not be temporary")
+	}
+}
+
+func expectAllowed(t *testing.T, b *Breaker, expectProbe bool) {
+	t.Helper()
+
+	probe, err := b.Allow(endpoint)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if probe != expectProbe {
+		t.Fatalf("expected probe %t, got %t", expectProbe, probe)
+	}
+}
+
+func expectOpen(t *testing.T, b *Breaker) *OpenError {
+	t.Helper()
+
+	_, err := b.Allow(endpoint)
+	var openErr *OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("expected OpenError, got %v", err)
+	}
+	return openErr
+}
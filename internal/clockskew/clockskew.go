@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package clockskew estimates the difference between the local clock and the clocks of AWS services.
+//
+// AWS rejects requests signed with Signature Version 4 when the signing time is too far from the time of the service,
+// with errors such as "RequestTimeTooSkewed" or "SignatureDoesNotMatch". The skew is estimated from the Date header of
+// responses, and can be added to the signing time to correct for a drifting local clock.
+package clockskew
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	// DefaultWarningThreshold is used when no warning threshold is configured
+	DefaultWarningThreshold = 5 * time.Minute
+
+	// minCorrection is the smallest skew that is corrected for, as the Date header has a resolution of one second
+	minCorrection = time.Second
+)
+
+// Tracker holds the most recently observed clock skew. It is safe for concurrent use.
+// A nil Tracker observes nothing and has no skew.
+type Tracker struct {
+	skew     atomic.Int64 // nanoseconds
+	observed atomic.Bool
+	warned   atomic.Bool
+}
+
+type trackerKeyT string
+
+const trackerKey trackerKeyT = "clock-skew-tracker"
+
+// NewContext returns a context carrying t.
+func NewContext(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerKey, t)
+}
+
+// FromContext returns the Tracker carried by ctx, or nil.
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey).(*Tracker)
+	return t
+}
+
+// configSource carries a Tracker in aws.Config.ConfigSources. The AWS SDK for Go v2 ignores unknown config sources.
+type configSource struct {
+	tracker *Tracker
+}
+
+// AddToConfig adds t to the config sources of cfg, so that clients created from cfg, including AWS SDK for Go v1
+// sessions, share it.
+func AddToConfig(cfg *aws.Config, t *Tracker) {
+	cfg.ConfigSources = append(cfg.ConfigSources, configSource{tracker: t})
+}
+
+// FromConfig returns the Tracker added to cfg, or nil.
+func FromConfig(cfg aws.Config) *Tracker {
+	for _, source := range cfg.ConfigSources {
+		if s, ok := source.(configSource); ok {
+			return s.tracker
+		}
+	}
+	return nil
+}
+
+// Observe records the skew between the Date header of a response and the local time. sent and received are the
+// local times at which the request was sent and the response received; the service time is assumed to be halfway
+// between them. It returns false if the response has no valid Date header.
+func (t *Tracker) Observe(resp *http.Response, sent, received time.Time) (time.Duration, bool) {
+	if t == nil || resp == nil {
+		return 0, false
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+
+	local := sent.Add(received.Sub(sent) / 2) //nolint:mnd // halfway
+	skew := date.Sub(local)
+
+	t.skew.Store(int64(skew))
+	t.observed.Store(true)
+
+	return skew, true
+}
+
+// Skew returns the most recently observed skew. A positive skew means that the local clock is behind.
+func (t *Tracker) Skew() (time.Duration, bool) {
+	if t == nil || !t.observed.Load() {
+		return 0, false
+	}
+	return time.Duration(t.skew.Load()), true
+}
+
+// Exceeds reports whether the most recently observed skew is larger than threshold in either direction.
+// If threshold is not positive, DefaultWarningThreshold is used.
+func (t *Tracker) Exceeds(threshold time.Duration) (time.Duration, bool) {
+	if threshold <= 0 {
+		threshold = DefaultWarningThreshold
+	}
+	skew, ok := t.Skew()
+	if !ok {
+		return 0, false
+	}
+	return skew, skew.Abs() > threshold
+}
+
+// Warn reports whether the skew exceeds threshold and no warning has been logged yet, so that a drifting clock is
+// logged once rather than for every response.
+func (t *Tracker) Warn(threshold time.Duration) (time.Duration, bool) {
+	skew, ok := t.Exceeds(threshold)
+	if !ok {
+		return 0, false
+	}
+	return skew, t.warned.CompareAndSwap(false, true)
+}
+
+// Offset returns the skew to add to the signing time. Skews smaller than the resolution of the Date header are ignored.
+func (t *Tracker) Offset() time.Duration {
+	skew, ok := t.Skew()
+	if !ok || skew.Abs() < minCorrection {
+		return 0
+	}
+	return skew
+}
+
+// Reset forgets the observed skew.
+func (t *Tracker) Reset() {
+	t.observed.Store(false)
+	t.skew.Store(0)
+	t.warned.Store(false)
+}
+
+// HTTPSigner matches the HTTPSignerV4 option of AWS SDK for Go v2 service clients.
+type HTTPSigner interface {
+	SignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request, payloadHash string, service string, region string, signingTime time.Time, optFns ...func(*v4.SignerOptions)) error
+}
+
+// Signer adds the observed skew to the signing time of each request. Newer versions of the AWS SDK for Go v2 correct
+// the signing time themselves, so a signing time that is already closer to the corrected time than to the local
+// time is left unchanged.
+type Signer struct {
+	signer  HTTPSigner
+	tracker *Tracker
+}
+
+var _ HTTPSigner = &Signer{}
+
+// NewSigner returns a Signer wrapping signer. If signer is nil, a default Signature Version 4 signer is used.
+// If signer already corrects for tracker, it is returned as is.
+func NewSigner(signer HTTPSigner, tracker *Tracker) *Signer {
+	if s, ok := signer.(*Signer); ok && s.tracker == tracker {
+		return s
+	}
+	if signer == nil {
+		signer = v4.NewSigner()
+	}
+	return &Signer{
+		signer:  signer,
+		tracker: tracker,
+	}
+}
+
+func (s *Signer) SignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request, payloadHash string, service string, region string, signingTime time.Time, optFns ...func(*v4.SignerOptions)) error {
+	if offset := s.tracker.Offset(); offset != 0 && time.Until(signingTime).Abs() < offset.Abs()/2 {
+		signingTime = signingTime.Add(offset)
+	}
+	return s.signer.SignHTTP(ctx, credentials, r, payloadHash, service, region, signingTime, optFns...)
+}
+
+// ServiceOption returns an aws.Config service option that wraps the HTTPSignerV4 of AWS SDK for Go v2 service clients
+// with a Signer for tracker. Service clients without an HTTPSignerV4 option are left unchanged.
+func ServiceOption(tracker *Tracker) func(string, any) {
+	return func(_ string, options any) {
+		v := reflect.ValueOf(options)
+		if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		field := v.Elem().FieldByName("HTTPSignerV4")
+		if !field.IsValid() || !field.CanSet() || field.Kind() != reflect.Interface {
+			return
+		}
+
+		var signer HTTPSigner
+		if !field.IsNil() {
+			var ok bool
+			if signer, ok = field.Interface().(HTTPSigner); !ok {
+				return
+			}
+		}
+
+		wrapped := reflect.ValueOf(NewSigner(signer, tracker))
+		if wrapped.Type().AssignableTo(field.Type()) {
+			field.Set(wrapped)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clockskew
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+func responseWithDate(date time.Time) *http.Response {
+	return &http.Response{
+		Header: http.Header{
+			"Date": []string{date.UTC().Format(http.TimeFormat)},
+		},
+	}
+}
+
+func TestTracker_Observe(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	testCases := map[string]struct {
+		response       *http.Response
+		sent, received time.Time
+		expectedOK     bool
+		expectedSkew   time.Duration
+	}{
+		"no response": {
+			sent:     now,
+			received: now,
+		},
+		"no Date header": {
+			response: &http.Response{Header: http.Header{}},
+			sent:     now,
+			received: now,
+		},
+		"invalid Date header": {
+			response: &http.Response{Header: http.Header{"Date": []string{"yesterday"}}},
+			sent:     now,
+			received: now,
+		},
+		"behind": {
+			response:     responseWithDate(now.Add(10 * time.Minute)),
+			sent:         now,
+			received:     now,
+			expectedOK:   true,
+			expectedSkew: 10 * time.Minute,
+		},
+		"ahead": {
+			response:     responseWithDate(now.Add(-10 * time.Minute)),
+			sent:         now,
+			received:     now,
+			expectedOK:   true,
+			expectedSkew: -10 * time.Minute,
+		},
+		"midpoint": {
+			response:     responseWithDate(now),
+			sent:         now.Add(-2 * time.Second),
+			received:     now.Add(2 * time.Second),
+			expectedOK:   true,
+			expectedSkew: 0,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tracker := &Tracker{}
+
+			skew, ok := tracker.Observe(testcase.response, testcase.sent, testcase.received)
+			if a, e := ok, testcase.expectedOK; a != e {
+				t.Fatalf("expected ok %t, got %t", e, a)
+			}
+			if a, e := skew, testcase.expectedSkew; a != e {
+				t.Errorf("expected skew %s, got %s", e, a)
+			}
+
+			skew, ok = tracker.Skew()
+			if a, e := ok, testcase.expectedOK; a != e {
+				t.Fatalf("expected Skew ok %t, got %t", e, a)
+			}
+			if a, e := skew, testcase.expectedSkew; a != e {
+				t.Errorf("expected Skew %s, got %s", e, a)
+			}
+		})
+	}
+}
+
+func TestTracker_Warn(t *testing.T) {
+	now := time.Now()
+	tracker := &Tracker{}
+
+	if _, ok := tracker.Warn(0); ok {
+		t.Error("expected no warning before a response is observed")
+	}
+
+	tracker.Observe(responseWithDate(now.Add(time.Minute)), now, now)
+	if _, ok := tracker.Exceeds(0); ok {
+		t.Error("expected skew not to exceed the default threshold")
+	}
+	if _, ok := tracker.Exceeds(30 * time.Second); !ok {
+		t.Error("expected skew to exceed a 30s threshold")
+	}
+
+	tracker.Observe(responseWithDate(now.Add(-time.Hour)), now, now)
+	if _, ok := tracker.Warn(0); !ok {
+		t.Error("expected warning")
+	}
+	if _, ok := tracker.Warn(0); ok {
+		t.Error("expected a single warning")
+	}
+
+	tracker.Reset()
+	if _, ok := tracker.Skew(); ok {
+		t.Error("expected no skew after Reset")
+	}
+}
+
+func TestTracker_Offset(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tracker := &Tracker{}
+
+	if a, e := tracker.Offset(), time.Duration(0); a != e {
+		t.Errorf("expected offset %s, got %s", e, a)
+	}
+
+	tracker.Observe(responseWithDate(now), now.Add(-400*time.Millisecond), now.Add(-400*time.Millisecond))
+	if a, e := tracker.Offset(), time.Duration(0); a != e {
+		t.Errorf("expected skew below the Date resolution to be ignored, got %s", a)
+	}
+
+	tracker.Observe(responseWithDate(now.Add(20*time.Minute)), now, now)
+	if a, e := tracker.Offset(), 20*time.Minute; a != e {
+		t.Errorf("expected offset %s, got %s", e, a)
+	}
+}
+
+type recordingSigner struct {
+	signingTime time.Time
+}
+
+func (s *recordingSigner) SignHTTP(_ context.Context, _ aws.Credentials, _ *http.Request, _ string, _ string, _ string, signingTime time.Time, _ ...func(*v4.SignerOptions)) error {
+	s.signingTime = signingTime
+	return nil
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tracker := &Tracker{}
+	tracker.Observe(responseWithDate(now.Add(-20*time.Minute)), now, now)
+
+	inner := &recordingSigner{}
+	signer := NewSigner(inner, tracker)
+
+	if err := signer.SignHTTP(context.Background(), aws.Credentials{}, &http.Request{}, "", "sts", "us-east-1", now); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := inner.signingTime, now.Add(-20*time.Minute); !a.Equal(e) {
+		t.Errorf("expected signing time %s, got %s", e, a)
+	}
+
+	// Signing times already corrected by the SDK are not corrected again
+	if err := signer.SignHTTP(context.Background(), aws.Credentials{}, &http.Request{}, "", "sts", "us-east-1", now.Add(-20*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := inner.signingTime, now.Add(-20*time.Minute); !a.Equal(e) {
+		t.Errorf("expected signing time %s, got %s", e, a)
+	}
+}
+
+func TestServiceOption(t *testing.T) {
+	tracker := &Tracker{}
+	inner := &recordingSigner{}
+
+	type signerOptions struct {
+		HTTPSignerV4 HTTPSigner
+	}
+	options := &signerOptions{HTTPSignerV4: inner}
+	ServiceOption(tracker)("STS", options)
+
+	signer, ok := options.HTTPSignerV4.(*Signer)
+	if !ok {
+		t.Fatalf("expected *Signer, got %T", options.HTTPSignerV4)
+	}
+	if signer.signer != inner || signer.tracker != tracker {
+		t.Errorf("expected signer wrapping the original signer")
+	}
+
+	// Applying the option again does not wrap twice
+	ServiceOption(tracker)("STS", options)
+	if options.HTTPSignerV4 != signer {
+		t.Errorf("expected signer to be unchanged")
+	}
+
+	type otherOptions struct {
+		Region string
+	}
+	other := &otherOptions{Region: "us-east-1"}
+	ServiceOption(tracker)("S3", other)
+	if a, e := other.Region, "us-east-1"; a != e {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tracker := &Tracker{}
+
+	var cfg aws.Config
+	if FromConfig(cfg) != nil {
+		t.Errorf("expected no tracker")
+	}
+	AddToConfig(&cfg, tracker)
+	if FromConfig(cfg) != tracker {
+		t.Errorf("expected tracker from config")
+	}
+
+	ctx := context.Background()
+	if FromContext(ctx) != nil {
+		t.Errorf("expected no tracker")
+	}
+	if FromContext(NewContext(ctx, tracker)) != tracker {
+		t.Errorf("expected tracker from context")
+	}
+}
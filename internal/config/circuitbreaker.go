@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/circuitbreaker"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+type dialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// endpointBreaker applies a circuit breaker to the endpoints requests are sent to, identified by the host and port
+// of the request URL.
+//
+// Direct connections are checked and recorded when the endpoint is dialed. When requests are sent through a proxy,
+// the dial to the proxy is not recorded against any endpoint. Instead, the CONNECT request for an HTTPS endpoint is
+// checked, and the proxy's response to it recorded, so that one failing endpoint does not open the breaker for
+// every endpoint behind the same proxy.
+type endpointBreaker struct {
+	breaker *circuitbreaker.Breaker
+
+	// proxies holds the addresses of the proxies chosen for requests
+	proxies sync.Map
+}
+
+func newEndpointBreaker(b *circuitbreaker.Breaker) *endpointBreaker {
+	return &endpointBreaker{
+		breaker: b,
+	}
+}
+
+func (e *endpointBreaker) apply(tr *http.Transport) {
+	next := tr.Proxy
+	tr.Proxy = func(req *http.Request) (*url.URL, error) {
+		if next == nil {
+			return nil, nil
+		}
+		proxy, err := next(req)
+		if proxy != nil {
+			e.proxies.Store(proxyAddress(proxy), struct{}{})
+		}
+		return proxy, err
+	}
+
+	tr.DialContext = withCircuitBreaker(e.breaker, tr.DialContext, e.isProxy)
+
+	getProxyConnectHeader := tr.GetProxyConnectHeader
+	tr.GetProxyConnectHeader = func(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error) {
+		if _, err := e.breaker.Allow(target); err != nil {
+			return nil, err
+		}
+		if getProxyConnectHeader == nil {
+			return nil, nil
+		}
+		return getProxyConnectHeader(ctx, proxyURL, target)
+	}
+
+	onProxyConnectResponse := tr.OnProxyConnectResponse
+	tr.OnProxyConnectResponse = func(ctx context.Context, proxyURL *url.URL, connectReq *http.Request, connectRes *http.Response) error {
+		e.recordConnect(ctx, connectReq.Host, connectRes)
+		if onProxyConnectResponse == nil {
+			return nil
+		}
+		return onProxyConnectResponse(ctx, proxyURL, connectReq, connectRes)
+	}
+}
+
+func (e *endpointBreaker) isProxy(address string) bool {
+	_, ok := e.proxies.Load(address)
+	return ok
+}
+
+// recordConnect records the proxy's response to a CONNECT request for target.
+// Only the gateway errors returned when the proxy cannot reach the endpoint count as network failures.
+// Any other unsuccessful response, such as a proxy authentication error, is not attributed to the endpoint.
+func (e *endpointBreaker) recordConnect(ctx context.Context, target string, res *http.Response) {
+	switch res.StatusCode {
+	case http.StatusOK:
+		e.breaker.Success(target)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if e.breaker.Failure(target) {
+			logCircuitBreakerOpened(ctx, target, fmt.Errorf("proxy CONNECT failed: %s", res.Status))
+		}
+	}
+}
+
+// withCircuitBreaker fails connection attempts immediately while the breaker for the dialed address is open.
+// Any failed dial counts as a network failure, unless the request's context was cancelled.
+// Dials to addresses for which skip returns true are not checked or recorded.
+func withCircuitBreaker(b *circuitbreaker.Breaker, next dialContextFunc, skip func(address string) bool) dialContextFunc {
+	if next == nil {
+		next = (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: dialKeepAlive,
+		}).DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if skip != nil && skip(address) {
+			return next(ctx, network, address)
+		}
+
+		probe, err := b.Allow(address)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := next(ctx, network, address)
+		switch {
+		case err == nil:
+			b.Success(address)
+		case ctx.Err() != nil:
+			if probe {
+				b.Abandon(address)
+			}
+		default:
+			if b.Failure(address) {
+				logCircuitBreakerOpened(ctx, address, err)
+			}
+		}
+
+		return conn, err
+	}
+}
+
+func logCircuitBreakerOpened(ctx context.Context, endpoint string, err error) {
+	logger := logging.RetrieveLogger(ctx)
+	logger.Warn(ctx, "Circuit breaker opened due to consecutive network failures", map[string]any{
+		"tf_aws.circuit_breaker.endpoint": endpoint,
+		"error":                           err,
+	})
+}
+
+// proxyAddress returns the host and port dialed for proxy, using the default port for its scheme if none is set.
+func proxyAddress(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+
+	port := "80"
+	switch proxy.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/circuitbreaker"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// Reserve a port with nothing listening on it
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %s", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	client := newClient(t, &Config{
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get("http://" + address)
+		if err == nil {
+			t.Fatalf("request %d: expected error, got none", i+1)
+		}
+		var openErr *circuitbreaker.OpenError
+		if errors.As(err, &openErr) {
+			t.Fatalf("request %d: expected connection error, got %s", i+1, err)
+		}
+	}
+
+	_, err = client.Get("http://" + address)
+	var openErr *circuitbreaker.OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("expected OpenError, got %v", err)
+	}
+	if a, e := openErr.Endpoint, address; a != e {
+		t.Errorf("expected endpoint %q, got %q", e, a)
+	}
+
+	// The state is shared with other clients using the same settings
+	other := newClient(t, &Config{
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  time.Hour,
+	})
+	if _, err := other.Get("http://" + address); !errors.As(err, &openErr) {
+		t.Errorf("expected OpenError from other client, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_proxy(t *testing.T) {
+	const (
+		failing = "failing.example.test:443"
+		healthy = "healthy.example.test:443"
+	)
+
+	endpoint := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	// The proxy cannot reach the failing endpoint, and tunnels to the test server for the healthy one
+	var connects atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "expected CONNECT", http.StatusMethodNotAllowed)
+			return
+		}
+		connects.Add(1)
+		if r.Host != healthy {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		upstream, err := net.Dial("tcp", endpoint.Listener.Addr().String())
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		go io.Copy(upstream, buf) //nolint:errcheck
+		io.Copy(conn, upstream)   //nolint:errcheck
+	}))
+	defer proxy.Close()
+
+	client := newClient(t, &Config{
+		HTTPSProxy:              aws.String(proxy.URL),
+		Insecure:                true,
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get("https://" + failing)
+		if err == nil {
+			t.Fatalf("request %d: expected error, got none", i+1)
+		}
+		var openErr *circuitbreaker.OpenError
+		if errors.As(err, &openErr) {
+			t.Fatalf("request %d: expected proxy error, got %s", i+1, err)
+		}
+	}
+
+	_, err := client.Get("https://" + failing)
+	var openErr *circuitbreaker.OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("expected OpenError, got %v", err)
+	}
+	if a, e := openErr.Endpoint, failing; a != e {
+		t.Errorf("expected endpoint %q, got %q", e, a)
+	}
+	if a, e := connects.Load(), int32(2); a != e {
+		t.Errorf("expected %d CONNECT requests, got %d", e, a)
+	}
+
+	// Other endpoints behind the same proxy are unaffected
+	resp, err := client.Get("https://" + healthy)
+	if err != nil {
+		t.Fatalf("expected no error for other endpoint, got %s", err)
+	}
+	resp.Body.Close()
+	if a, e := resp.StatusCode, http.StatusNoContent; a != e {
+		t.Errorf("expected status %d, got %d", e, a)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	dialErr := errors.New("dial tcp: lookup sts.example.test: no such host")
+
+	testcases := map[string]struct {
+		cancel        bool
+		expectFailure bool
+	}{
+		"network failure": {
+			expectFailure: true,
+		},
+		"cancelled": {
+			cancel: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			b := circuitbreaker.New(1, time.Hour)
+
+			var dials int
+			dial := withCircuitBreaker(b, func(ctx context.Context, network, address string) (net.Conn, error) {
+				dials++
+				return nil, dialErr
+			}, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if testcase.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			if _, err := dial(ctx, "tcp", "sts.example.test:443"); !errors.Is(err, dialErr) {
+				t.Fatalf("expected dial error, got %v", err)
+			}
+
+			_, err := b.Allow("sts.example.test:443")
+			if isOpen := err != nil; isOpen != testcase.expectFailure {
+				t.Errorf("expected breaker open %t, got %t", testcase.expectFailure, isOpen)
+			}
+		})
+	}
+}
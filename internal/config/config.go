@@ -0,0 +1,397 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/circuitbreaker"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/expand"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http/httpproxy"
+)
+
+type ProxyMode int
+
+const (
+	HTTPProxyModeLegacy ProxyMode = iota
+	HTTPProxyModeSeparate
+
+	// HTTPProxyModePAC selects the proxy for each request by evaluating the proxy auto-config (PAC) script at ProxyPACURL.
+	// NO_PROXY entries, including CIDR ranges, are applied before the script is evaluated.
+	HTTPProxyModePAC
+)
+
+type Config struct {
+	AccessKey                      string
+	AllowedAccountIds              []string
+	APNInfo                        *APNInfo
+	AssumeRole                     []AssumeRole
+	AssumeRoleWithWebIdentity      *AssumeRoleWithWebIdentity
+	Backoff                        retry.BackoffDelayer
+	CallerDocumentationURL         string
+	CallerName                     string
+	CustomCABundle                 string
+	EC2MetadataServiceEnableState  imds.ClientEnableState
+	EC2MetadataServiceEndpoint     string
+	EC2MetadataServiceEndpointMode string
+	ForbiddenAccountIds            []string
+	HTTPClient                     *http.Client
+	HTTPProxy                      *string
+	HTTPSProxy                     *string
+	IamEndpoint                    string
+	Insecure                       bool
+	Logger                         logging.Logger
+	MaxBackoff                     time.Duration
+	MaxRetries                     int
+	NoProxy                        string
+	Profile                        string
+	HTTPProxyMode                  ProxyMode
+	Region                         string
+	RetryMode                      aws.RetryMode
+	SecretKey                      string
+	SharedCredentialsFiles         []string
+	SharedConfigFiles              []string
+	SkipCredsValidation            bool
+	SkipRequestingAccountId        bool
+	SsoEndpoint                    string
+	StsEndpoint                    string
+	StsRegion                      string
+	SuppressDebugLog               bool
+	Token                          string
+	TokenBucketRateLimiterCapacity int
+	UseDualStackEndpoint           bool
+	UseFIPSEndpoint                bool
+	UserAgent                      UserAgentProducts
+
+	// Connection settings, applied to the default HTTP clients of both the AWS SDK for Go v1 and v2
+
+	// DNSResolverAddress is the address, with an optional port, of a DNS server used instead of the system resolver.
+	DNSResolverAddress string
+	// HostOverrides maps hostnames to the IP address or hostname, with an optional port, that is dialed instead.
+	HostOverrides map[string]string
+	// TLSMinVersion is the minimum TLS version, "1.2" or "1.3".
+	TLSMinVersion string
+	// TLSCipherSuites restricts TLS 1.2 connections to the cipher suites with the given IANA names.
+	TLSCipherSuites []string
+	// TLSPinnedPublicKeys maps hostnames, or "*.suffix" wildcards, to base64-encoded SHA-256 hashes of the
+	// SubjectPublicKeyInfo of a certificate, at least one of which must be in the server's verified chain.
+	TLSPinnedPublicKeys map[string][]string
+	// ProxyPACURL is the http, https or file URL, or local path, of the PAC script used with HTTPProxyModePAC.
+	ProxyPACURL string
+	// CircuitBreakerThreshold is the number of consecutive connection failures after which an endpoint is no longer
+	// dialed. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long an open circuit breaker waits before allowing a probe connection.
+	// Zero uses a default of 30 seconds.
+	CircuitBreakerCooldown time.Duration
+
+	// Observability settings
+
+	// TracerProvider creates OpenTelemetry spans for AWS API calls. Nil disables tracing.
+	TracerProvider trace.TracerProvider
+	// MeterProvider records OpenTelemetry metrics for AWS API calls. Nil disables metrics.
+	MeterProvider metric.MeterProvider
+	// RedactionRules are applied to logged and exported bodies, in addition to the built-in rules.
+	RedactionRules []logging.RedactionRule
+	// HAR exports AWS API traffic to a HAR file. Nil disables the export.
+	// The caller closes the file with HAR.Close once the AWS clients are no longer used.
+	HAR *logging.HARConfig
+	// LogVerbosity selects which AWS API requests and responses are logged. The zero value logs everything.
+	LogVerbosity logging.Verbosity
+	// ServiceLogVerbosity overrides LogVerbosity by service ID. SuppressDebugLog turns logging off for all services.
+	ServiceLogVerbosity map[string]logging.Verbosity
+	// LogSampleRate logs only 1 in LogSampleRate successful responses, when greater than 1.
+	LogSampleRate int
+
+	// ClockSkewWarningThreshold is the clock skew, measured from response Date headers, above which a warning is
+	// logged. Zero uses a default of 5 minutes.
+	ClockSkewWarningThreshold time.Duration
+	// CorrectClockSkew signs requests using the local time adjusted by the measured clock skew.
+	CorrectClockSkew bool
+
+	// MaxNetworkErrorRetries is the number of retries after which NonRetryableNetworkErrors are no longer retried.
+	// Zero uses a default of 9.
+	MaxNetworkErrorRetries int
+	// NonRetryableNetworkErrors are the classes of networking errors that are not retried after
+	// MaxNetworkErrorRetries. Nil uses NoSuchHost and ConnectionRefused.
+	NonRetryableNetworkErrors []netretry.Class
+}
+
+type AssumeRole struct {
+	RoleARN           string
+	Duration          time.Duration
+	ExternalID        string
+	Policy            string
+	PolicyARNs        []string
+	SessionName       string
+	SourceIdentity    string
+	Tags              map[string]string
+	TransitiveTagKeys []string
+}
+
+func (c Config) CustomCABundleReader() (*bytes.Reader, error) {
+	if c.CustomCABundle == "" {
+		return nil, nil
+	}
+	bundleFile, err := expand.FilePath(c.CustomCABundle)
+	if err != nil {
+		return nil, fmt.Errorf("expanding custom CA bundle: %w", err)
+	}
+	bundle, err := os.ReadFile(bundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading custom CA bundle: %w", err)
+	}
+	return bytes.NewReader(bundle), nil
+}
+
+// HTTPTransportOptions returns functional options that configures an http.Transport.
+// In addition to the proxy and TLS settings, it applies the connection-level, TLS policy, proxy selection and
+// circuit breaker settings.
+// The returned options function is called on both AWS SDKv1 and v2 default HTTP clients.
+func (c Config) HTTPTransportOptions() (func(*http.Transport), error) {
+	var err error
+	var httpProxyUrl *url.URL
+	if c.HTTPProxy != nil {
+		httpProxyUrl, err = url.Parse(aws.ToString(c.HTTPProxy))
+		if err != nil {
+			return nil, fmt.Errorf("parsing HTTP proxy URL: %w", err)
+		}
+	}
+	var httpsProxyUrl *url.URL
+	if c.HTTPSProxy != nil {
+		httpsProxyUrl, err = url.Parse(aws.ToString(c.HTTPSProxy))
+		if err != nil {
+			return nil, fmt.Errorf("parsing HTTPS proxy URL: %w", err)
+		}
+	}
+
+	opts := func(tr *http.Transport) {
+		tr.MaxIdleConnsPerHost = awshttp.DefaultHTTPTransportMaxIdleConnsPerHost
+
+		tlsConfig := tr.TLSClientConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			}
+			tr.TLSClientConfig = tlsConfig
+		}
+
+		if c.Insecure {
+			tr.TLSClientConfig.InsecureSkipVerify = true
+		}
+
+		proxyConfig := httpproxy.FromEnvironment()
+		if httpProxyUrl != nil {
+			proxyConfig.HTTPProxy = httpProxyUrl.String()
+			if c.HTTPProxyMode == HTTPProxyModeLegacy && proxyConfig.HTTPSProxy == "" {
+				proxyConfig.HTTPSProxy = httpProxyUrl.String()
+			}
+		}
+		if httpsProxyUrl != nil {
+			proxyConfig.HTTPSProxy = httpsProxyUrl.String()
+		}
+		if c.NoProxy != "" {
+			proxyConfig.NoProxy = c.NoProxy
+		}
+		tr.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyConfig.ProxyFunc()(req.URL)
+		}
+	}
+
+	dialer, err := newDialer(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := newTLSPolicy(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	var pacClient *http.Client
+	if c.HTTPProxyMode == HTTPProxyModePAC {
+		tr, err := newPACTransport(&c, opts, policy, dialer)
+		if err != nil {
+			return nil, err
+		}
+		pacClient = &http.Client{Transport: tr}
+	}
+
+	proxy, err := newProxySelector(&c, dialer, pacClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaker *endpointBreaker
+	if c.CircuitBreakerThreshold > 0 {
+		breaker = newEndpointBreaker(circuitbreaker.Shared(c.CircuitBreakerThreshold, c.CircuitBreakerCooldown))
+	}
+
+	return func(tr *http.Transport) {
+		opts(tr)
+
+		tr.Proxy = proxy.wrap(tr.Proxy)
+
+		if policy != nil {
+			policy.apply(tr)
+		}
+
+		if dialer != nil {
+			tr.DialContext = dialer.DialContext
+		}
+
+		if breaker != nil {
+			breaker.apply(tr)
+		}
+	}, nil
+}
+
+func (c Config) ValidateProxySettings(diags *diag.Diagnostics) {
+	if c.HTTPProxy != nil {
+		if _, err := url.Parse(aws.ToString(c.HTTPProxy)); err != nil {
+			*diags = diags.AddError(
+				"Invalid HTTP Proxy",
+				fmt.Sprintf("Unable to parse URL: %s", err),
+			)
+		}
+	}
+
+	if c.HTTPSProxy != nil {
+		if _, err := url.Parse(aws.ToString(c.HTTPSProxy)); err != nil {
+			*diags = diags.AddError(
+				"Invalid HTTPS Proxy",
+				fmt.Sprintf("Unable to parse URL: %s", err),
+			)
+		}
+	}
+
+	if c.HTTPProxy != nil && *c.HTTPProxy != "" && c.HTTPSProxy == nil && os.Getenv("HTTPS_PROXY") == "" && os.Getenv("https_proxy") == "" {
+		if c.HTTPProxyMode == HTTPProxyModeLegacy {
+			*diags = diags.Append(
+				missingHttpsProxyLegacyWarningDiag(aws.ToString(c.HTTPProxy)),
+			)
+		} else {
+			*diags = diags.Append(
+				missingHttpsProxyWarningDiag(),
+			)
+		}
+	}
+}
+
+const (
+	missingHttpsProxyWarningSummary   = "Missing HTTPS Proxy"
+	missingHttpsProxyDetailProblem    = "An HTTP proxy was set but no HTTPS proxy was."
+	missingHttpsProxyDetailResolution = "To specify no proxy for HTTPS, set the HTTPS to an empty string."
+)
+
+func missingHttpsProxyLegacyWarningDiag(s string) diag.Diagnostic {
+	return diag.NewWarningDiagnostic(
+		missingHttpsProxyWarningSummary,
+		fmt.Sprintf(
+			missingHttpsProxyDetailProblem+" Using HTTP proxy %q for HTTPS requests. This behavior may change in future versions.\n\n"+
+				missingHttpsProxyDetailResolution,
+			s,
+		),
+	)
+}
+
+func missingHttpsProxyWarningDiag() diag.Diagnostic {
+	return diag.NewWarningDiagnostic(
+		missingHttpsProxyWarningSummary,
+		missingHttpsProxyDetailProblem+"\n\n"+
+			missingHttpsProxyDetailResolution,
+	)
+}
+
+func (c Config) ResolveSharedConfigFiles() ([]string, error) {
+	v, err := expand.FilePaths(c.SharedConfigFiles)
+	if err != nil {
+		return []string{}, fmt.Errorf("expanding shared config files: %w", err)
+	}
+	return v, nil
+}
+
+func (c Config) ResolveSharedCredentialsFiles() ([]string, error) {
+	v, err := expand.FilePaths(c.SharedCredentialsFiles)
+	if err != nil {
+		return []string{}, fmt.Errorf("expanding shared credentials files: %w", err)
+	}
+	return v, nil
+}
+
+// VerifyAccountIDAllowed verifies an account ID is not explicitly forbidden
+// or omitted from an allow list, if configured.
+//
+// If the AllowedAccountIds and ForbiddenAccountIds fields are both empty, this
+// function will return nil.
+func (c Config) VerifyAccountIDAllowed(accountID string) error {
+	if len(c.ForbiddenAccountIds) > 0 {
+		if slices.Contains(c.ForbiddenAccountIds, accountID) {
+			return fmt.Errorf("AWS account ID not allowed: %s", accountID)
+		}
+	}
+	if len(c.AllowedAccountIds) > 0 {
+		found := slices.Contains(c.AllowedAccountIds, accountID)
+		if !found {
+			return fmt.Errorf("AWS account ID not allowed: %s", accountID)
+		}
+	}
+	return nil
+}
+
+type AssumeRoleWithWebIdentity struct {
+	RoleARN              string
+	Duration             time.Duration
+	Policy               string
+	PolicyARNs           []string
+	SessionName          string
+	WebIdentityToken     string
+	WebIdentityTokenFile string
+}
+
+func (c AssumeRoleWithWebIdentity) resolveWebIdentityTokenFile() (string, error) {
+	v, err := expand.FilePath(c.WebIdentityTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("expanding web identity token file: %w", err)
+	}
+	return v, nil
+}
+
+func (c AssumeRoleWithWebIdentity) HasValidTokenSource() bool {
+	return c.WebIdentityToken != "" || c.WebIdentityTokenFile != ""
+}
+
+// Implements `stscreds.IdentityTokenRetriever`
+func (c AssumeRoleWithWebIdentity) GetIdentityToken() ([]byte, error) {
+	if c.WebIdentityToken != "" {
+		return []byte(c.WebIdentityToken), nil
+	}
+	webIdentityTokenFile, err := c.resolveWebIdentityTokenFile()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(webIdentityTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %w", webIdentityTokenFile, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Matches the dialer settings of both the AWS SDK for Go v2 and go-cleanhttp default transports
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+
+	defaultDNSPort = "53"
+)
+
+// dialer resolves static host overrides before dialing and, optionally, uses a custom DNS server.
+// Only the dialed address is changed, so the request URL, and therefore the TLS SNI and
+// certificate verification, still use the original hostname.
+type dialer struct {
+	net.Dialer
+
+	// overrides maps lower-cased hostnames to a replacement host and optional port
+	overrides map[string]hostOverride
+}
+
+type hostOverride struct {
+	host string
+	port string
+}
+
+// newDialer returns nil if no connection-level settings are configured.
+func newDialer(c *Config) (*dialer, error) {
+	if len(c.HostOverrides) == 0 && c.DNSResolverAddress == "" {
+		return nil, nil
+	}
+
+	d := &dialer{
+		Dialer: net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: dialKeepAlive,
+		},
+	}
+
+	if len(c.HostOverrides) > 0 {
+		d.overrides = make(map[string]hostOverride, len(c.HostOverrides))
+		for host, target := range c.HostOverrides {
+			if host == "" {
+				return nil, fmt.Errorf("parsing host overrides: hostname cannot be empty")
+			}
+			override, err := parseHostOverride(target)
+			if err != nil {
+				return nil, fmt.Errorf("parsing host override for %q: %w", host, err)
+			}
+			d.overrides[strings.ToLower(host)] = override
+		}
+	}
+
+	if v := c.DNSResolverAddress; v != "" {
+		address, err := resolverAddress(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DNS resolver address: %w", err)
+		}
+		resolverDialer := &net.Dialer{
+			Timeout: dialTimeout,
+		}
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return resolverDialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+
+	return d, nil
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d.Dialer.DialContext(ctx, network, d.overrideAddress(address))
+}
+
+func (d *dialer) overrideAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	override, ok := d.overrides[strings.ToLower(host)]
+	if !ok {
+		return address
+	}
+	if override.port != "" {
+		port = override.port
+	}
+	return net.JoinHostPort(override.host, port)
+}
+
+// lookupIP resolves host the same way the dialer would, applying host overrides and the custom DNS server.
+// It can be called on a nil dialer, in which case the system resolver is used.
+func (d *dialer) lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	resolver := net.DefaultResolver
+	if d != nil {
+		if override, ok := d.overrides[strings.ToLower(host)]; ok {
+			host = override.host
+		}
+		if d.Resolver != nil {
+			resolver = d.Resolver
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
+
+// parseHostOverride accepts an IP address or hostname, optionally with a port.
+func parseHostOverride(v string) (hostOverride, error) {
+	if v == "" {
+		return hostOverride{}, fmt.Errorf("target cannot be empty")
+	}
+
+	if ip := net.ParseIP(strings.Trim(v, "[]")); ip != nil {
+		return hostOverride{host: ip.String()}, nil
+	}
+
+	if !strings.Contains(v, ":") {
+		return hostOverride{host: v}, nil
+	}
+
+	host, port, err := net.SplitHostPort(v)
+	if err != nil {
+		return hostOverride{}, err
+	}
+	if host == "" {
+		return hostOverride{}, fmt.Errorf("target %q has no host", v)
+	}
+	if err := validatePort(port); err != nil {
+		return hostOverride{}, err
+	}
+
+	return hostOverride{host: host, port: port}, nil
+}
+
+// resolverAddress returns the address of a DNS server, defaulting to port 53.
+func resolverAddress(v string) (string, error) {
+	if ip := net.ParseIP(strings.Trim(v, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), defaultDNSPort), nil
+	}
+
+	if !strings.Contains(v, ":") {
+		return net.JoinHostPort(v, defaultDNSPort), nil
+	}
+
+	host, port, err := net.SplitHostPort(v)
+	if err != nil {
+		return "", err
+	}
+	if err := validatePort(port); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func validatePort(port string) error {
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 { //nolint:mnd
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHostOverrides(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	serverURL, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %s", err)
+	}
+
+	testcases := map[string]struct {
+		target string
+		url    string
+	}{
+		"IP address": {
+			target: serverURL.Hostname(),
+			url:    "http://sts.example.test:" + serverURL.Port(),
+		},
+		"IP address and port": {
+			target: serverURL.Host,
+			url:    "http://sts.example.test",
+		},
+		"hostname case insensitive": {
+			target: serverURL.Host,
+			url:    "http://STS.Example.Test",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			client := newClient(t, &Config{
+				HostOverrides: map[string]string{
+					"sts.example.test": testcase.target,
+				},
+			})
+
+			resp, err := client.Get(testcase.url)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			resp.Body.Close()
+
+			if a, e := resp.StatusCode, http.StatusNoContent; a != e {
+				t.Errorf("expected status code %d, got %d", e, a)
+			}
+		})
+	}
+}
+
+func TestHostOverrides_preservesServerName(t *testing.T) {
+	var serverName string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverName = r.TLS.ServerName
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	serverURL, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %s", err)
+	}
+
+	client := newClient(t, &Config{
+		Insecure: true,
+		HostOverrides: map[string]string{
+			"iam.example.test": serverURL.Host,
+		},
+	})
+
+	resp, err := client.Get("https://iam.example.test")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if a, e := serverName, "iam.example.test"; a != e {
+		t.Errorf("expected TLS server name %q, got %q", e, a)
+	}
+}
+
+func TestDNSResolverAddress(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %s", err)
+	}
+	defer conn.Close()
+
+	d, err := newDialer(&Config{
+		DNSResolverAddress: conn.LocalAddr().String(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	received := make(chan struct{})
+	go func() {
+		buf := make([]byte, 512) //nolint:mnd
+		if _, _, err := conn.ReadFrom(buf); err == nil {
+			close(received)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// No response is sent, so the lookup is expected to fail
+	_, _ = d.Resolver.LookupHost(ctx, "sts.example.test")
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("expected DNS query to be sent to custom resolver")
+	}
+}
+
+func TestNewDialer_validation(t *testing.T) {
+	testcases := map[string]struct {
+		config      Config
+		expectError bool
+	}{
+		"no settings": {},
+		"valid overrides": {
+			config: Config{
+				HostOverrides: map[string]string{
+					"a.example.test": "10.0.0.1",
+					"b.example.test": "10.0.0.1:8443",
+					"c.example.test": "fd00::1",
+					"d.example.test": "[fd00::1]:8443",
+					"e.example.test": "vpce.example.test",
+				},
+			},
+		},
+		"empty hostname": {
+			config: Config{
+				HostOverrides: map[string]string{
+					"": "10.0.0.1",
+				},
+			},
+			expectError: true,
+		},
+		"empty target": {
+			config: Config{
+				HostOverrides: map[string]string{
+					"a.example.test": "",
+				},
+			},
+			expectError: true,
+		},
+		"invalid port": {
+			config: Config{
+				HostOverrides: map[string]string{
+					"a.example.test": "10.0.0.1:https",
+				},
+			},
+			expectError: true,
+		},
+		"valid resolver": {
+			config: Config{
+				DNSResolverAddress: "10.0.0.2",
+			},
+		},
+		"invalid resolver port": {
+			config: Config{
+				DNSResolverAddress: "10.0.0.2:0",
+			},
+			expectError: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newDialer(&testcase.config)
+
+			if testcase.expectError && err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if !testcase.expectError && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestResolverAddress(t *testing.T) {
+	testcases := map[string]string{
+		"10.0.0.2":         "10.0.0.2:53",
+		"10.0.0.2:5353":    "10.0.0.2:5353",
+		"fd00::2":          "[fd00::2]:53",
+		"[fd00::2]:5353":   "[fd00::2]:5353",
+		"dns.example.test": "dns.example.test:53",
+	}
+
+	for input, expected := range testcases {
+		t.Run(input, func(t *testing.T) {
+			got, err := resolverAddress(input)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func newClient(t *testing.T, c *Config) *http.Client {
+	t.Helper()
+
+	opts, err := c.HTTPTransportOptions()
+	if err != nil {
+		t.Fatalf("creating transport options: %s", err)
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	opts(tr)
+
+	return &http.Client{Transport: tr}
+}
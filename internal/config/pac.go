@@ -0,0 +1,696 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+const (
+	// pacEvalTimeout bounds a single call to FindProxyForURL, including any DNS lookups it makes
+	pacEvalTimeout = 5 * time.Second
+
+	// pacLookupTimeout bounds each DNS lookup made by a PAC script, so that a single slow lookup does not use the
+	// whole evaluation timeout while other requests wait for the script
+	pacLookupTimeout = 1 * time.Second
+
+	// pacLoadTimeout bounds fetching a PAC script
+	pacLoadTimeout = 30 * time.Second
+
+	// pacRefreshInterval is how long a PAC script is used before it is fetched again in the background
+	pacRefreshInterval = 1 * time.Hour
+
+	// pacResultTTL is how long the proxy chosen for a URL is cached
+	pacResultTTL = 1 * time.Minute
+
+	maxPACScriptLen = 1 << 20
+)
+
+// pacNow returns the current time for the PAC date and time functions. It is replaced in tests.
+var pacNow = time.Now
+
+var (
+	pacLoadersMu sync.Mutex
+	pacLoaders   = make(map[string]*pacLoader)
+)
+
+// pacLoader holds a proxy auto-config (PAC) script and fetches it again, in the background, once it is older
+// than pacRefreshInterval. If fetching fails, the previous script continues to be used.
+type pacLoader struct {
+	location string
+	client   *http.Client
+	lookupIP func(context.Context, string) ([]net.IP, error)
+
+	mu        sync.Mutex
+	script    *pacScript
+	loadedAt  time.Time
+	reloading bool
+}
+
+// sharedPACLoader returns a process-wide pacLoader for location, so that the AWS SDK for Go v1 and v2 clients,
+// and every client created with the same PAC URL, fetch the script once.
+// The first caller's HTTP client and DNS lookup function are used.
+func sharedPACLoader(ctx context.Context, location string, client *http.Client, lookupIP func(context.Context, string) ([]net.IP, error)) (*pacLoader, error) {
+	pacLoadersMu.Lock()
+	defer pacLoadersMu.Unlock()
+
+	if l, ok := pacLoaders[location]; ok {
+		return l, nil
+	}
+
+	l := &pacLoader{
+		location: location,
+		client:   client,
+		lookupIP: lookupIP,
+	}
+	script, err := l.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l.script = script
+	l.loadedAt = time.Now()
+
+	pacLoaders[location] = l
+	return l, nil
+}
+
+func (l *pacLoader) load(ctx context.Context) (*pacScript, error) {
+	src, err := fetchPACScript(ctx, l.client, l.location)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPACScript(string(src), l.lookupIP)
+}
+
+// findProxy evaluates the current script, starting a background refresh if it is due.
+func (l *pacLoader) findProxy(ctx context.Context, u *url.URL) (*url.URL, error) {
+	return l.current(ctx).findProxy(ctx, u)
+}
+
+func (l *pacLoader) current(ctx context.Context) *pacScript {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.reloading && time.Since(l.loadedAt) >= pacRefreshInterval {
+		l.reloading = true
+		go l.reload(context.WithoutCancel(ctx))
+	}
+
+	return l.script
+}
+
+func (l *pacLoader) reload(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pacLoadTimeout)
+	defer cancel()
+
+	script, err := l.load(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reloading = false
+	l.loadedAt = time.Now()
+	if err != nil {
+		logger := logging.RetrieveLogger(ctx)
+		logger.Warn(ctx, "Reloading proxy auto-config failed, continuing to use the previous script", map[string]any{
+			"error": err,
+		})
+		return
+	}
+	l.script = script
+}
+
+// newPACTransport returns the transport used to fetch a proxy auto-config script.
+// It uses the same TLS, dialer and DNS settings as AWS API requests, but the script itself is always fetched directly.
+func newPACTransport(c *Config, opts func(*http.Transport), policy *tlsPolicy, dialer *dialer) (*http.Transport, error) {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	opts(tr)
+	tr.Proxy = nil
+
+	if policy != nil {
+		policy.apply(tr)
+	}
+
+	if dialer != nil {
+		tr.DialContext = dialer.DialContext
+	}
+
+	reader, err := c.CustomCABundleReader()
+	if err != nil {
+		return nil, err
+	}
+	if reader != nil {
+		bundle, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("reading custom CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("custom CA bundle contains no certificates")
+		}
+		tr.TLSClientConfig.RootCAs = pool
+	}
+
+	return tr, nil
+}
+
+// fetchPACScript fetches a PAC script from an http, https or file URL, or a local path.
+func fetchPACScript(ctx context.Context, client *http.Client, location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching %s: unexpected status %s", location, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxPACScriptLen))
+
+	case "file":
+		return os.ReadFile(u.Path)
+
+	case "":
+		return os.ReadFile(location)
+
+	default:
+		return nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+}
+
+// pacScript evaluates a proxy auto-config (PAC) script.
+// The JavaScript runtime is not safe for concurrent use, so evaluations are serialized. Results for HTTPS URLs,
+// whose path is not passed to the script, are cached per host for pacResultTTL.
+type pacScript struct {
+	mu sync.Mutex
+
+	vm               *goja.Runtime
+	findProxyForURL  goja.Callable
+	lookupIP         func(context.Context, string) ([]net.IP, error)
+	ctx              context.Context // the context of the current evaluation, used for DNS lookups
+	myIPAddressValue string
+
+	resultsMu sync.Mutex
+	results   map[string]pacResult
+}
+
+type pacResult struct {
+	value   string
+	expires time.Time
+}
+
+func newPACScript(src string, lookupIP func(context.Context, string) ([]net.IP, error)) (*pacScript, error) {
+	p := &pacScript{
+		vm:       goja.New(),
+		lookupIP: lookupIP,
+		ctx:      context.Background(),
+		results:  make(map[string]pacResult),
+	}
+
+	builtins := map[string]any{
+		"isPlainHostName":     isPlainHostName,
+		"dnsDomainIs":         dnsDomainIs,
+		"localHostOrDomainIs": localHostOrDomainIs,
+		"isResolvable":        p.isResolvable,
+		"isInNet":             p.isInNet,
+		"dnsResolve":          p.dnsResolve,
+		"convert_addr":        convertAddr,
+		"myIpAddress":         p.myIPAddress,
+		"dnsDomainLevels":     dnsDomainLevels,
+		"shExpMatch":          shExpMatch,
+		"weekdayRange":        p.stringArgs(weekdayRange),
+		"dateRange":           p.stringArgs(dateRange),
+		"timeRange":           p.stringArgs(timeRange),
+		"alert":               func(string) {},
+	}
+	for name, f := range builtins {
+		if err := p.vm.Set(name, f); err != nil {
+			return nil, fmt.Errorf("registering %s: %w", name, err)
+		}
+	}
+
+	if _, err := p.vm.RunString(src); err != nil {
+		return nil, fmt.Errorf("evaluating script: %w", err)
+	}
+
+	f, ok := goja.AssertFunction(p.vm.Get("FindProxyForURL"))
+	if !ok {
+		return nil, fmt.Errorf("script does not define function FindProxyForURL")
+	}
+	p.findProxyForURL = f
+
+	return p, nil
+}
+
+// findProxy returns the first supported proxy returned by FindProxyForURL, or nil for DIRECT.
+// Only the first entry is used, because http.Transport does not support proxy failover.
+func (p *pacScript) findProxy(ctx context.Context, u *url.URL) (*url.URL, error) {
+	result, err := p.result(ctx, pacURL(u), u.Hostname(), u.Scheme == "https")
+	if err != nil {
+		return nil, fmt.Errorf("evaluating proxy auto-config for %s: %w", u.Host, err)
+	}
+
+	return parsePACResult(result)
+}
+
+// result returns the cached result for rawURL, evaluating the script if there is none.
+// Concurrent requests for the same URL wait for a single evaluation.
+// Only results for URLs without a path, that is HTTPS URLs, are cached, so that the cache is bounded by the number of hosts.
+func (p *pacScript) result(ctx context.Context, rawURL, host string, cacheable bool) (string, error) {
+	if !cacheable {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		return p.evaluate(ctx, rawURL, host)
+	}
+
+	if result, ok := p.cachedResult(rawURL); ok {
+		return result, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.cachedResult(rawURL); ok {
+		return result, nil
+	}
+
+	result, err := p.evaluate(ctx, rawURL, host)
+	if err != nil {
+		return "", err
+	}
+
+	p.resultsMu.Lock()
+	p.results[rawURL] = pacResult{
+		value:   result,
+		expires: time.Now().Add(pacResultTTL),
+	}
+	p.resultsMu.Unlock()
+
+	return result, nil
+}
+
+func (p *pacScript) cachedResult(rawURL string) (string, bool) {
+	p.resultsMu.Lock()
+	defer p.resultsMu.Unlock()
+
+	result, ok := p.results[rawURL]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(result.expires) {
+		delete(p.results, rawURL)
+		return "", false
+	}
+	return result.value, true
+}
+
+// evaluate calls FindProxyForURL. The caller must hold p.mu.
+func (p *pacScript) evaluate(ctx context.Context, rawURL, host string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, pacEvalTimeout)
+	defer cancel()
+
+	p.ctx = ctx
+	timer := time.AfterFunc(pacEvalTimeout, func() {
+		p.vm.Interrupt("timed out")
+	})
+	defer func() {
+		timer.Stop()
+		p.vm.ClearInterrupt()
+		p.ctx = context.Background()
+	}()
+
+	v, err := p.findProxyForURL(goja.Undefined(), p.vm.ToValue(rawURL), p.vm.ToValue(host))
+	if err != nil {
+		return "", err
+	}
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return "", nil
+	}
+	return v.String(), nil
+}
+
+// pacURL returns the URL passed to FindProxyForURL.
+// As in most browsers, the path and query of HTTPS URLs are removed, and credentials are never passed.
+func pacURL(u *url.URL) string {
+	v := *u
+	v.User = nil
+	if v.Scheme == "https" {
+		v.Path = "/"
+		v.RawPath = ""
+		v.RawQuery = ""
+		v.Fragment = ""
+	}
+	return v.String()
+}
+
+func parsePACResult(result string) (*url.URL, error) {
+	if strings.TrimSpace(result) == "" {
+		return nil, nil
+	}
+
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var scheme string
+		switch strings.ToUpper(fields[0]) {
+		case "DIRECT":
+			return nil, nil
+		case "PROXY", "HTTP":
+			scheme = "http"
+		case "HTTPS":
+			scheme = "https"
+		case "SOCKS", "SOCKS5":
+			scheme = "socks5"
+		default:
+			continue
+		}
+		if len(fields) != 2 { //nolint:mnd
+			continue
+		}
+
+		return &url.URL{
+			Scheme: scheme,
+			Host:   fields[1],
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no supported proxy in %q", result)
+}
+
+func (p *pacScript) resolve(host string) net.IP {
+	ctx, cancel := context.WithTimeout(p.ctx, pacLookupTimeout)
+	defer cancel()
+
+	ips, err := p.lookupIP(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if v4 := ip.To4(); v4 != nil {
+			return v4
+		}
+	}
+	return nil
+}
+
+func (p *pacScript) isResolvable(host string) bool {
+	return p.resolve(host) != nil
+}
+
+func (p *pacScript) dnsResolve(host string) any {
+	if ip := p.resolve(host); ip != nil {
+		return ip.String()
+	}
+	return nil
+}
+
+func (p *pacScript) isInNet(host, pattern, mask string) bool {
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		ip = p.resolve(host)
+	}
+	patternIP := net.ParseIP(pattern).To4()
+	maskIP := net.ParseIP(mask).To4()
+	if ip == nil || patternIP == nil || maskIP == nil {
+		return false
+	}
+
+	m := net.IPMask(maskIP)
+	return ip.Mask(m).Equal(patternIP.Mask(m))
+}
+
+func (p *pacScript) myIPAddress() string {
+	if p.myIPAddressValue == "" {
+		p.myIPAddressValue = localIPv4Address()
+	}
+	return p.myIPAddressValue
+}
+
+// localIPv4Address returns the first non-loopback IPv4 address of the host.
+func localIPv4Address() string {
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+				if v4 := ipNet.IP.To4(); v4 != nil {
+					return v4.String()
+				}
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+func isPlainHostName(host string) bool {
+	return !strings.Contains(host, ".")
+}
+
+func dnsDomainIs(host, domain string) bool {
+	return strings.HasSuffix(strings.ToLower(host), strings.ToLower(domain))
+}
+
+func localHostOrDomainIs(host, hostdom string) bool {
+	host, hostdom = strings.ToLower(host), strings.ToLower(hostdom)
+	if host == hostdom {
+		return true
+	}
+	return !strings.Contains(host, ".") && strings.HasPrefix(hostdom, host+".")
+}
+
+func dnsDomainLevels(host string) int {
+	return strings.Count(host, ".")
+}
+
+func convertAddr(ip string) uint32 {
+	v4 := net.ParseIP(ip).To4()
+	if v4 == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(v4)
+}
+
+// shExpMatch matches a shell expression, where "*" matches any sequence of characters and "?" any single character.
+// Unlike path.Match, "*" also matches "/".
+func shExpMatch(s, shexp string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range shexp {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+var (
+	pacWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+	pacMonths   = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+)
+
+// stringArgs adapts a function taking a variable number of arguments, which may be strings or numbers.
+func (p *pacScript) stringArgs(f func([]string) bool) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		args := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.String()
+		}
+		return p.vm.ToValue(f(args))
+	}
+}
+
+// pacTime returns the current time and the arguments without a trailing "GMT".
+func pacTime(args []string) (time.Time, []string) {
+	now := pacNow()
+	if n := len(args); n > 0 && strings.EqualFold(args[n-1], "GMT") {
+		return now.UTC(), args[:n-1]
+	}
+	return now.Local(), args
+}
+
+// inRange reports whether v is in the inclusive range from start to end, which wraps around if start > end.
+func inRange(v, start, end int) bool {
+	if start <= end {
+		return start <= v && v <= end
+	}
+	return v >= start || v <= end
+}
+
+func weekdayRange(args []string) bool {
+	now, args := pacTime(args)
+	if len(args) == 0 || len(args) > 2 { //nolint:mnd
+		return false
+	}
+
+	days := make([]int, len(args))
+	for i, arg := range args {
+		days[i] = indexOf(pacWeekdays, arg)
+		if days[i] < 0 {
+			return false
+		}
+	}
+
+	today := int(now.Weekday())
+	if len(days) == 1 {
+		return today == days[0]
+	}
+	return inRange(today, days[0], days[1])
+}
+
+func dateRange(args []string) bool {
+	now, args := pacTime(args)
+	n := len(args)
+	if n == 0 || n > 6 || (n > 1 && n%2 != 0) { //nolint:mnd
+		return false
+	}
+
+	type datePart struct {
+		kind  int // 0: year, 1: month, 2: day, in order of significance
+		value int
+	}
+	current := [3]int{now.Year(), int(now.Month()), now.Day()}
+
+	parts := make([]datePart, n)
+	for i, s := range args {
+		if m := indexOf(pacMonths, s); m >= 0 {
+			parts[i] = datePart{kind: 1, value: m + 1}
+			continue
+		}
+		v, err := strconv.Atoi(s)
+		switch {
+		case err != nil:
+			return false
+		case v >= 1 && v <= 31:
+			parts[i] = datePart{kind: 2, value: v}
+		case v >= 1000:
+			parts[i] = datePart{kind: 0, value: v}
+		default:
+			return false
+		}
+	}
+
+	if n == 1 {
+		return current[parts[0].kind] == parts[0].value
+	}
+
+	start, end := parts[:n/2], parts[n/2:]
+	for i := range start {
+		if start[i].kind != end[i].kind {
+			return false
+		}
+	}
+
+	var startKey, endKey, currentKey int
+	for kind := 0; kind < 3; kind++ {
+		var s, e int
+		found := false
+		for i := range start {
+			if start[i].kind == kind {
+				s, found = start[i].value, true
+			}
+			if end[i].kind == kind {
+				e = end[i].value
+			}
+		}
+		if !found {
+			continue
+		}
+		startKey = startKey*100 + s
+		endKey = endKey*100 + e
+		currentKey = currentKey*100 + current[kind]
+	}
+	return inRange(currentKey, startKey, endKey)
+}
+
+func timeRange(args []string) bool {
+	now, args := pacTime(args)
+
+	values := make([]int, len(args))
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return false
+		}
+		values[i] = v
+	}
+
+	seconds := func(h, m, s int) int { return h*3600 + m*60 + s }
+	current := seconds(now.Hour(), now.Minute(), now.Second())
+
+	var start, end int
+	switch len(values) {
+	case 1:
+		return now.Hour() == values[0]
+	case 2: //nolint:mnd
+		start, end = seconds(values[0], 0, 0), seconds(values[1], 0, 0)
+	case 4: //nolint:mnd
+		start, end = seconds(values[0], values[1], 0), seconds(values[2], values[3], 0)
+	case 6: //nolint:mnd
+		start, end = seconds(values[0], values[1], values[2]), seconds(values[3], values[4], values[5])
+	default:
+		return false
+	}
+
+	// The end of the range is exclusive
+	return inRange(current, start, end-1)
+}
+
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, s) {
+			return i
+		}
+	}
+	return -1
+}
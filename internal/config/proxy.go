@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// Resolved addresses are cached briefly so that NO_PROXY CIDR matching does not add a DNS lookup to every request
+	ipCacheTTL = 1 * time.Minute
+)
+
+// proxySelector chooses the proxy for each request.
+// It adds proxy auto-config (PAC) evaluation and matching of NO_PROXY CIDR ranges against resolved addresses
+// to the static proxy settings from Config.HTTPTransportOptions, and records the choice on the request's
+// logging.ConnectionTrace.
+type proxySelector struct {
+	pac *pacLoader
+
+	// noProxy reports whether a URL matches a NO_PROXY entry. It is only used with PAC, because otherwise the static
+	// proxy function already applies NO_PROXY.
+	noProxy func(*url.URL) bool
+
+	// cidrs are the NO_PROXY entries that are CIDR ranges
+	cidrs []*net.IPNet
+
+	ips *ipCache
+}
+
+// pacClient is used to fetch a PAC script from an http or https URL.
+func newProxySelector(c *Config, d *dialer, pacClient *http.Client) (*proxySelector, error) {
+	noProxy := c.NoProxy
+	if noProxy == "" {
+		noProxy = httpproxy.FromEnvironment().NoProxy
+	}
+
+	p := &proxySelector{
+		cidrs: parseNoProxyCIDRs(noProxy),
+		ips: &ipCache{
+			lookup:  d.lookupIP,
+			entries: make(map[string]ipCacheEntry),
+		},
+	}
+
+	if c.HTTPProxyMode == HTTPProxyModePAC {
+		if c.ProxyPACURL == "" {
+			return nil, fmt.Errorf("proxy auto-config URL must be set when using proxy mode PAC")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), pacLoadTimeout)
+		defer cancel()
+
+		pac, err := sharedPACLoader(ctx, c.ProxyPACURL, pacClient, p.ips.lookupIP)
+		if err != nil {
+			return nil, fmt.Errorf("loading proxy auto-config: %w", err)
+		}
+		p.pac = pac
+
+		// Any non-empty proxy makes the ProxyFunc return nil only for excluded URLs
+		matcher := (&httpproxy.Config{
+			HTTPProxy:  "http://proxy.invalid",
+			HTTPSProxy: "http://proxy.invalid",
+			NoProxy:    noProxy,
+		}).ProxyFunc()
+		p.noProxy = func(u *url.URL) bool {
+			proxy, err := matcher(u)
+			return err == nil && proxy == nil
+		}
+	}
+
+	return p, nil
+}
+
+// wrap returns an http.Transport Proxy function that falls back to next when no PAC script is configured.
+func (p *proxySelector) wrap(next func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy, err := p.choose(req, next)
+		if err != nil {
+			return nil, err
+		}
+
+		if trace := logging.ConnectionTraceFromContext(req.Context()); trace != nil {
+			trace.SetProxy(proxy)
+		}
+
+		return proxy, nil
+	}
+}
+
+func (p *proxySelector) choose(req *http.Request, next func(*http.Request) (*url.URL, error)) (*url.URL, error) {
+	if p.bypass(req) {
+		return nil, nil
+	}
+
+	if p.pac != nil {
+		return p.pac.findProxy(req.Context(), req.URL)
+	}
+
+	if next != nil {
+		return next(req)
+	}
+	return nil, nil
+}
+
+// bypass reports whether the request should be sent directly because of a NO_PROXY entry.
+func (p *proxySelector) bypass(req *http.Request) bool {
+	if p.noProxy != nil && p.noProxy(req.URL) {
+		return true
+	}
+
+	if len(p.cidrs) == 0 {
+		return false
+	}
+
+	// IP addresses in the URL are already matched by httpproxy
+	host := req.URL.Hostname()
+	if net.ParseIP(host) != nil {
+		return false
+	}
+
+	// A failed lookup will also fail when dialing, so report the dial error instead
+	ips, err := p.ips.lookupIP(req.Context(), host)
+	if err != nil {
+		return false
+	}
+
+	for _, ip := range ips {
+		for _, cidr := range p.cidrs {
+			if cidr.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseNoProxyCIDRs returns the entries of a comma-separated NO_PROXY value that are CIDR ranges.
+func parseNoProxyCIDRs(noProxy string) []*net.IPNet {
+	var cidrs []*net.IPNet
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+type ipCache struct {
+	lookup func(context.Context, string) ([]net.IP, error)
+
+	mu      sync.Mutex
+	entries map[string]ipCacheEntry
+}
+
+type ipCacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+func (c *ipCache) lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	key := strings.ToLower(host)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	ips, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = ipCacheEntry{
+		ips:     ips,
+		expires: time.Now().Add(ipCacheTTL),
+	}
+	c.mu.Unlock()
+
+	return ips, nil
+}
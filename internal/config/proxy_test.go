@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+func TestProxyPAC(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("parsing proxy URL: %s", err)
+	}
+
+	pacFile := filepath.Join(t.TempDir(), "proxy.pac")
+	script := fmt.Sprintf(`function FindProxyForURL(url, host) {
+	if (dnsDomainIs(host, ".example.test")) {
+		return "PROXY %s; DIRECT";
+	}
+	return "DIRECT";
+}`, proxyURL.Host)
+	if err := os.WriteFile(pacFile, []byte(script), 0600); err != nil {
+		t.Fatalf("writing PAC file: %s", err)
+	}
+
+	client := newClient(t, &Config{
+		HTTPProxyMode: HTTPProxyModePAC,
+		ProxyPACURL:   "file://" + pacFile,
+	})
+
+	trace := logging.NewConnectionTrace()
+	ctx := logging.WithConnectionTrace(context.Background(), trace)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://sts.example.test/", nil)
+	if err != nil {
+		t.Fatalf("creating request: %s", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if a, e := proxiedHost, "sts.example.test"; a != e {
+		t.Errorf("expected proxied request for %q, got %q", e, a)
+	}
+
+	expectProxyAttribute(t, trace, "http://"+proxyURL.Host)
+}
+
+func TestProxyPAC_httpURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ns-proxy-autoconfig")
+		fmt.Fprint(w, `function FindProxyForURL(url, host) {
+	if (shExpMatch(url, "https://*.amazonaws.com/*")) {
+		return "HTTPS proxy.example.test:8443";
+	}
+	return "DIRECT";
+}`)
+	}))
+	defer ts.Close()
+
+	tr := newTransport(t, &Config{
+		HTTPProxyMode: HTTPProxyModePAC,
+		ProxyPACURL:   ts.URL + "/proxy.pac",
+	})
+
+	testcases := map[string]string{
+		"https://sts.us-west-2.amazonaws.com/?Action=GetCallerIdentity": "https://proxy.example.test:8443",
+		"http://sts.us-west-2.amazonaws.com/":                           logging.ProxyDirect,
+		"https://sts.example.test/":                                     logging.ProxyDirect,
+	}
+
+	for rawURL, expected := range testcases {
+		t.Run(rawURL, func(t *testing.T) {
+			expectProxy(t, tr, rawURL, expected)
+		})
+	}
+}
+
+func TestProxyPAC_fetchedOnce(t *testing.T) {
+	var fetches int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprint(w, `function FindProxyForURL(url, host) { return "PROXY proxy.example.test:3128"; }`)
+	}))
+	defer ts.Close()
+
+	c := &Config{
+		HTTPProxyMode: HTTPProxyModePAC,
+		ProxyPACURL:   ts.URL + "/proxy.pac",
+	}
+
+	// Once each for the AWS SDK for Go v1 and v2
+	for range 2 {
+		tr := newTransport(t, c)
+		expectProxy(t, tr, "https://sts.example.test/", "http://proxy.example.test:3128")
+	}
+
+	if a, e := fetches, 1; a != e {
+		t.Errorf("expected %d fetches, got %d", e, a)
+	}
+}
+
+func TestProxyPAC_reload(t *testing.T) {
+	pacFile := filepath.Join(t.TempDir(), "proxy.pac")
+	writeScript := func(proxy string) {
+		t.Helper()
+		script := fmt.Sprintf(`function FindProxyForURL(url, host) { return "PROXY %s"; }`, proxy)
+		if err := os.WriteFile(pacFile, []byte(script), 0600); err != nil {
+			t.Fatalf("writing PAC file: %s", err)
+		}
+	}
+	writeScript("first.example.test:3128")
+
+	loader, err := sharedPACLoader(context.Background(), pacFile, nil, (*dialer)(nil).lookupIP)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reload := func() {
+		t.Helper()
+		loader.mu.Lock()
+		loader.loadedAt = time.Now().Add(-pacRefreshInterval)
+		loader.mu.Unlock()
+
+		loader.current(context.Background())
+
+		for {
+			loader.mu.Lock()
+			reloading := loader.reloading
+			loader.mu.Unlock()
+			if !reloading {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	u, _ := url.Parse("https://sts.example.test/")
+	expectPACProxy := func(expected string) {
+		t.Helper()
+		proxy, err := loader.findProxy(context.Background(), u)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if a, e := proxy.String(), expected; a != e {
+			t.Errorf("expected proxy %q, got %q", e, a)
+		}
+	}
+
+	writeScript("second.example.test:3128")
+	reload()
+	expectPACProxy("http://second.example.test:3128")
+
+	if err := os.Remove(pacFile); err != nil {
+		t.Fatalf("removing PAC file: %s", err)
+	}
+	reload()
+	expectPACProxy("http://second.example.test:3128")
+}
+
+func TestProxyPAC_noProxy(t *testing.T) {
+	pacFile := filepath.Join(t.TempDir(), "proxy.pac")
+	if err := os.WriteFile(pacFile, []byte(`function FindProxyForURL(url, host) { return "PROXY proxy.example.test:3128"; }`), 0600); err != nil {
+		t.Fatalf("writing PAC file: %s", err)
+	}
+
+	tr := newTransport(t, &Config{
+		HTTPProxyMode: HTTPProxyModePAC,
+		ProxyPACURL:   pacFile,
+		NoProxy:       "iam.example.test,10.0.0.0/8",
+		HostOverrides: map[string]string{
+			"vpce.example.test": "10.1.2.3",
+		},
+	})
+
+	testcases := map[string]string{
+		"https://sts.example.test/":  "http://proxy.example.test:3128",
+		"https://iam.example.test/":  logging.ProxyDirect,
+		"https://vpce.example.test/": logging.ProxyDirect,
+		"https://10.1.2.3/":          logging.ProxyDirect,
+	}
+
+	for rawURL, expected := range testcases {
+		t.Run(rawURL, func(t *testing.T) {
+			expectProxy(t, tr, rawURL, expected)
+		})
+	}
+}
+
+func TestNoProxyCIDR(t *testing.T) {
+	testcases := map[string]struct {
+		noProxy  string
+		expected string
+	}{
+		"matching CIDR": {
+			noProxy:  "10.0.0.0/8",
+			expected: logging.ProxyDirect,
+		},
+		"matching IPv6 CIDR": {
+			noProxy:  "example.test, fd00::/8",
+			expected: logging.ProxyDirect,
+		},
+		"non-matching CIDR": {
+			noProxy:  "192.168.0.0/16",
+			expected: "http://proxy.example.test:3128",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			tr := newTransport(t, &Config{
+				HTTPProxy:  aws.String("http://proxy.example.test:3128"),
+				HTTPSProxy: aws.String("http://proxy.example.test:3128"),
+				NoProxy:    testcase.noProxy,
+				HostOverrides: map[string]string{
+					"sts.example.test": "10.1.2.3",
+					"iam.example.test": "fd00::1",
+				},
+			})
+
+			host := "sts.example.test"
+			if name == "matching IPv6 CIDR" {
+				host = "iam.example.test"
+			}
+
+			expectProxy(t, tr, "https://"+host+"/", testcase.expected)
+		})
+	}
+}
+
+func TestProxyPAC_configErrors(t *testing.T) {
+	testcases := map[string]Config{
+		"missing URL": {
+			HTTPProxyMode: HTTPProxyModePAC,
+		},
+		"missing file": {
+			HTTPProxyMode: HTTPProxyModePAC,
+			ProxyPACURL:   filepath.Join(t.TempDir(), "missing.pac"),
+		},
+		"unsupported scheme": {
+			HTTPProxyMode: HTTPProxyModePAC,
+			ProxyPACURL:   "ftp://example.test/proxy.pac",
+		},
+	}
+
+	for name, c := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := c.HTTPTransportOptions(); err == nil {
+				t.Fatal("expected error, got none")
+			}
+		})
+	}
+}
+
+func newTransport(t *testing.T, c *Config) *http.Transport {
+	t.Helper()
+
+	return newClient(t, c).Transport.(*http.Transport)
+}
+
+// expectProxy checks the proxy chosen for a request and that it is recorded on the connection trace.
+func expectProxy(t *testing.T, tr *http.Transport, rawURL, expected string) {
+	t.Helper()
+
+	trace := logging.NewConnectionTrace()
+	ctx := logging.WithConnectionTrace(context.Background(), trace)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatalf("creating request: %s", err)
+	}
+
+	proxy, err := tr.Proxy(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	actual := logging.ProxyDirect
+	if proxy != nil {
+		actual = proxy.String()
+	}
+	if actual != expected {
+		t.Errorf("expected proxy %q, got %q", expected, actual)
+	}
+
+	expectProxyAttribute(t, trace, expected)
+}
+
+func expectProxyAttribute(t *testing.T, trace *logging.ConnectionTrace, expected string) {
+	t.Helper()
+
+	for _, attr := range trace.Attributes() {
+		if attr.Key == logging.ProxyKey {
+			if a := attr.Value.AsString(); a != expected {
+				t.Errorf("expected %q attribute %q, got %q", logging.ProxyKey, expected, a)
+			}
+			return
+		}
+	}
+	t.Errorf("expected %q attribute", logging.ProxyKey)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
+)
+
+// serviceRequestIDError is implemented by AWS SDK for Go v2 response errors
+type serviceRequestIDError interface {
+	error
+	ServiceRequestID() string
+}
+
+// serviceHostIDError is implemented by Amazon S3 response errors for the AWS SDK for Go v2
+type serviceHostIDError interface {
+	error
+	ServiceHostID() string
+}
+
+// requestFailure is implemented by AWS SDK for Go v1 response errors, awserr.RequestFailure
+type requestFailure interface {
+	error
+	RequestID() string
+}
+
+// hostIDError is implemented by Amazon S3 response errors for the AWS SDK for Go v1
+type hostIDError interface {
+	error
+	HostID() string
+}
+
+// RequestIDs returns the AWS request ID and the S3 extended request ID from an error returned by an AWS API call
+// made with either the AWS SDK for Go v1 or v2.
+func RequestIDs(err error) (requestID, extendedRequestID string) {
+	if e, ok := errs.As[serviceRequestIDError](err); ok {
+		requestID = e.ServiceRequestID()
+	} else if e, ok := errs.As[requestFailure](err); ok {
+		requestID = e.RequestID()
+	}
+	if e, ok := errs.As[serviceHostIDError](err); ok {
+		extendedRequestID = e.ServiceHostID()
+	} else if e, ok := errs.As[hostIDError](err); ok {
+		extendedRequestID = e.HostID()
+	}
+	return requestID, extendedRequestID
+}
+
+// RequestIDDetail formats the AWS request IDs for inclusion in a diagnostic, so that they can be quoted in AWS support cases.
+func RequestIDDetail(requestID, extendedRequestID string) string {
+	var b strings.Builder
+	if requestID != "" {
+		fmt.Fprintf(&b, "AWS Request ID: %s\n", requestID)
+	}
+	if extendedRequestID != "" {
+		fmt.Fprintf(&b, "AWS Extended Request ID: %s\n", extendedRequestID)
+	}
+	return b.String()
+}
+
+// WithRequestIDs appends the AWS request IDs of a failed API call to the error message, for diagnostics that only
+// include the error. The error is returned unchanged if it has no request IDs.
+func WithRequestIDs(err error) error {
+	requestID, extendedRequestID := RequestIDs(err)
+	if requestID == "" && extendedRequestID == "" {
+		return err
+	}
+	return requestIDError{
+		err:               err,
+		requestID:         requestID,
+		extendedRequestID: extendedRequestID,
+	}
+}
+
+// requestIDError adds the AWS request IDs of a failed API call to the error message.
+type requestIDError struct {
+	err error
+
+	requestID, extendedRequestID string
+}
+
+func (e requestIDError) Error() string {
+	return fmt.Sprintf("%s\n%s", e.err, RequestIDDetail(e.requestID, e.extendedRequestID))
+}
+
+func (e requestIDError) Unwrap() error {
+	return e.err
+}
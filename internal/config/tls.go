@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsPolicy holds the validated TLS settings applied to the transport's tls.Config.
+type tlsPolicy struct {
+	minVersion   uint16
+	cipherSuites []uint16
+
+	// pins maps lower-cased hostnames, or "*.suffix" wildcards, to the set of
+	// accepted base64-encoded SHA-256 hashes of a certificate's SubjectPublicKeyInfo
+	pins map[string]map[string]struct{}
+}
+
+// newTLSPolicy returns nil if no TLS settings are configured.
+func newTLSPolicy(c *Config) (*tlsPolicy, error) {
+	if c.TLSMinVersion == "" && len(c.TLSCipherSuites) == 0 && len(c.TLSPinnedPublicKeys) == 0 {
+		return nil, nil
+	}
+
+	p := &tlsPolicy{}
+
+	if v := c.TLSMinVersion; v != "" {
+		version, ok := tlsVersions[v]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS minimum version %q: must be one of \"1.2\" or \"1.3\"", v)
+		}
+		p.minVersion = version
+	}
+
+	if len(c.TLSCipherSuites) > 0 {
+		suites, err := parseCipherSuites(c.TLSCipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		p.cipherSuites = suites
+	}
+
+	if len(c.TLSPinnedPublicKeys) > 0 {
+		p.pins = make(map[string]map[string]struct{}, len(c.TLSPinnedPublicKeys))
+		for host, hashes := range c.TLSPinnedPublicKeys {
+			if host == "" || host == "*." {
+				return nil, fmt.Errorf("parsing TLS pinned public keys: hostname cannot be empty")
+			}
+			if len(hashes) == 0 {
+				return nil, fmt.Errorf("parsing TLS pinned public keys for %q: at least one hash is required", host)
+			}
+			set := make(map[string]struct{}, len(hashes))
+			for _, hash := range hashes {
+				if b, err := base64.StdEncoding.DecodeString(hash); err != nil || len(b) != sha256.Size {
+					return nil, fmt.Errorf("parsing TLS pinned public keys for %q: %q is not a base64-encoded SHA-256 hash", host, hash)
+				}
+				set[hash] = struct{}{}
+			}
+			p.pins[strings.ToLower(host)] = set
+		}
+	}
+
+	return p, nil
+}
+
+// parseCipherSuites converts IANA cipher suite names to IDs.
+// Only suites supported by the Go TLS stack for TLS 1.2 are accepted; TLS 1.3 suites are not configurable.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	available := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		suite, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS cipher suite %q", name)
+		}
+		if !supportsVersion(suite, tls.VersionTLS12) {
+			return nil, fmt.Errorf("TLS cipher suite %q cannot be configured: TLS 1.3 cipher suites are not configurable", name)
+		}
+		ids = append(ids, suite.ID)
+	}
+
+	return ids, nil
+}
+
+func supportsVersion(suite *tls.CipherSuite, version uint16) bool {
+	for _, v := range suite.SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *tlsPolicy) apply(tr *http.Transport) {
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	tlsConfig := tr.TLSClientConfig
+
+	if p.minVersion != 0 {
+		tlsConfig.MinVersion = p.minVersion
+	}
+	if len(p.cipherSuites) > 0 {
+		tlsConfig.CipherSuites = p.cipherSuites
+	}
+	if len(p.pins) > 0 {
+		tlsConfig.VerifyConnection = p.verifyConnection
+	}
+}
+
+// verifyConnection is called after normal certificate verification.
+// A connection passes if any certificate in a verified chain matches one of the pins for the host.
+// Certificates that the server presents but that are not part of a verified chain are ignored, as they prove nothing.
+// When certificate verification is disabled there are no verified chains, and only the leaf certificate is checked;
+// the handshake still proves that the server holds its private key.
+func (p *tlsPolicy) verifyConnection(cs tls.ConnectionState) error {
+	host := strings.ToLower(cs.ServerName)
+
+	pins, ok := p.pinsFor(host)
+	if !ok {
+		return nil
+	}
+
+	var hashes []string
+	for _, cert := range pinCandidates(cs) {
+		hash := publicKeyHash(cert)
+		if _, ok := pins[hash]; ok {
+			return nil
+		}
+		if !slices.Contains(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return &PinningError{
+		Host:   cs.ServerName,
+		Hashes: hashes,
+	}
+}
+
+// pinCandidates returns the certificates that may match a pin, leaf first.
+func pinCandidates(cs tls.ConnectionState) []*x509.Certificate {
+	if len(cs.VerifiedChains) == 0 {
+		if len(cs.PeerCertificates) == 0 {
+			return nil
+		}
+		return cs.PeerCertificates[:1]
+	}
+
+	var certs []*x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	return certs
+}
+
+// pinsFor returns the pins for an exact hostname match, falling back to the longest matching wildcard.
+func (p *tlsPolicy) pinsFor(host string) (map[string]struct{}, bool) {
+	if host == "" {
+		return nil, false
+	}
+	if pins, ok := p.pins[host]; ok {
+		return pins, true
+	}
+	for suffix := host; ; {
+		i := strings.IndexByte(suffix, '.')
+		if i < 0 {
+			return nil, false
+		}
+		suffix = suffix[i+1:]
+		if pins, ok := p.pins["*."+suffix]; ok {
+			return pins, true
+		}
+	}
+}
+
+func publicKeyHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// PinningError is returned when no verified certificate presented by a server matches the public keys pinned for its hostname.
+type PinningError struct {
+	Host string
+
+	// Hashes are the base64-encoded SHA-256 SubjectPublicKeyInfo hashes of the certificates checked against the pins
+	Hashes []string
+}
+
+func (e *PinningError) Error() string {
+	return fmt.Sprintf("TLS certificate pinning failed for %q: no presented certificate matches a pinned public key (presented: %s)", e.Host, strings.Join(e.Hashes, ", "))
+}
+
+// RetryableError prevents the AWS SDK for Go v2 from retrying the request.
+func (e *PinningError) RetryableError() bool {
+	return false
+}
+
+// Temporary prevents the AWS SDK for Go v1 from retrying the request.
+func (e *PinningError) Temporary() bool {
+	return false
+}
+
+// AsPinningError returns the PinningError in err's chain, if any.
+func AsPinningError(err error) (*PinningError, bool) {
+	var pe *PinningError
+	ok := errors.As(err, &pe)
+	return pe, ok
+}
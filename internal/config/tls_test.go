@@ -0,0 +1,379 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	// base64-encoded SHA-256 of an empty input
+	otherPublicKeyHash = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+)
+
+func TestTLSPinnedPublicKeys(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	serverURL, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %s", err)
+	}
+
+	serverHash := publicKeyHash(ts.Certificate())
+
+	testcases := map[string]struct {
+		pins        map[string][]string
+		expectError bool
+	}{
+		"exact match": {
+			pins: map[string][]string{
+				"sts.example.test": {otherPublicKeyHash, serverHash},
+			},
+		},
+		"wildcard match": {
+			pins: map[string][]string{
+				"*.example.test": {serverHash},
+			},
+		},
+		"exact takes precedence over wildcard": {
+			pins: map[string][]string{
+				"*.example.test":   {serverHash},
+				"sts.example.test": {otherPublicKeyHash},
+			},
+			expectError: true,
+		},
+		"mismatch": {
+			pins: map[string][]string{
+				"sts.example.test": {otherPublicKeyHash},
+			},
+			expectError: true,
+		},
+		"other host not pinned": {
+			pins: map[string][]string{
+				"iam.example.test": {otherPublicKeyHash},
+			},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			client := newClient(t, &Config{
+				Insecure: true,
+				HostOverrides: map[string]string{
+					"sts.example.test": serverURL.Host,
+				},
+				TLSPinnedPublicKeys: testcase.pins,
+			})
+
+			resp, err := client.Get("https://sts.example.test")
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			if !testcase.expectError {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+			pe, ok := AsPinningError(err)
+			if !ok {
+				t.Fatalf("expected PinningError, got %T: %s", err, err)
+			}
+			if a, e := pe.Host, "sts.example.test"; a != e {
+				t.Errorf("expected host %q, got %q", e, a)
+			}
+			if len(pe.Hashes) == 0 || pe.Hashes[0] != serverHash {
+				t.Errorf("expected presented hashes to start with %q, got %v", serverHash, pe.Hashes)
+			}
+		})
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{
+		MaxVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	testcases := map[string]struct {
+		version     string
+		expectError bool
+	}{
+		"default": {},
+		"1.2": {
+			version: "1.2",
+		},
+		"1.3": {
+			version:     "1.3",
+			expectError: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			client := newClient(t, &Config{
+				Insecure:      true,
+				TLSMinVersion: testcase.version,
+			})
+
+			resp, err := client.Get(ts.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			if testcase.expectError && err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if !testcase.expectError && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestTLSCipherSuites(t *testing.T) {
+	policy, err := newTLSPolicy(&Config{
+		TLSCipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tlsConfig := &tls.Config{}
+	policy.apply(&http.Transport{TLSClientConfig: tlsConfig})
+
+	expected := []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	}
+	if len(tlsConfig.CipherSuites) != len(expected) {
+		t.Fatalf("expected cipher suites %v, got %v", expected, tlsConfig.CipherSuites)
+	}
+	for i, id := range expected {
+		if tlsConfig.CipherSuites[i] != id {
+			t.Errorf("expected cipher suites %v, got %v", expected, tlsConfig.CipherSuites)
+			break
+		}
+	}
+}
+
+func TestTLSPolicyVerifyConnection(t *testing.T) {
+	leaf := newTestCertificate(t)
+	root := newTestCertificate(t)
+	unverified := newTestCertificate(t)
+
+	testcases := map[string]struct {
+		state       tls.ConnectionState
+		pin         *x509.Certificate
+		expectError bool
+	}{
+		"verified root": {
+			state: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, root},
+				VerifiedChains:   [][]*x509.Certificate{{leaf, root}},
+			},
+			pin: root,
+		},
+		"unverified certificate": {
+			state: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, unverified},
+				VerifiedChains:   [][]*x509.Certificate{{leaf, root}},
+			},
+			pin:         unverified,
+			expectError: true,
+		},
+		"verification disabled leaf": {
+			state: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, unverified},
+			},
+			pin: leaf,
+		},
+		"verification disabled unverified certificate": {
+			state: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, unverified},
+			},
+			pin:         unverified,
+			expectError: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			policy, err := newTLSPolicy(&Config{
+				TLSPinnedPublicKeys: map[string][]string{
+					"sts.example.test": {publicKeyHash(testcase.pin)},
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			testcase.state.ServerName = "sts.example.test"
+			err = policy.verifyConnection(testcase.state)
+
+			if testcase.expectError && err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if !testcase.expectError && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestTLSPolicyApply_noTLSConfig(t *testing.T) {
+	policy, err := newTLSPolicy(&Config{
+		TLSMinVersion: "1.3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tr := &http.Transport{}
+	policy.apply(tr)
+
+	if tr.TLSClientConfig == nil {
+		t.Fatal("expected TLS config, got none")
+	}
+	if a, e := tr.TLSClientConfig.MinVersion, uint16(tls.VersionTLS13); a != e {
+		t.Errorf("expected minimum version %x, got %x", e, a)
+	}
+}
+
+func TestNewTLSPolicy_validation(t *testing.T) {
+	testcases := map[string]struct {
+		config      Config
+		expectError bool
+	}{
+		"no settings": {},
+		"invalid minimum version": {
+			config: Config{
+				TLSMinVersion: "1.1",
+			},
+			expectError: true,
+		},
+		"unknown cipher suite": {
+			config: Config{
+				TLSCipherSuites: []string{"TLS_NOT_A_SUITE"},
+			},
+			expectError: true,
+		},
+		"insecure cipher suite": {
+			config: Config{
+				TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			},
+			expectError: true,
+		},
+		"TLS 1.3 cipher suite": {
+			config: Config{
+				TLSCipherSuites: []string{"TLS_AES_128_GCM_SHA256"},
+			},
+			expectError: true,
+		},
+		"empty pinned hostname": {
+			config: Config{
+				TLSPinnedPublicKeys: map[string][]string{
+					"": {otherPublicKeyHash},
+				},
+			},
+			expectError: true,
+		},
+		"no pinned hashes": {
+			config: Config{
+				TLSPinnedPublicKeys: map[string][]string{
+					"sts.example.test": {},
+				},
+			},
+			expectError: true,
+		},
+		"invalid pinned hash": {
+			config: Config{
+				TLSPinnedPublicKeys: map[string][]string{
+					"sts.example.test": {"not-a-hash"},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTLSPolicy(&testcase.config)
+
+			if testcase.expectError && err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if !testcase.expectError && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestPinningError_notRetryable(t *testing.T) {
+	var err error = &PinningError{Host: "sts.example.test"}
+
+	var retryable interface{ RetryableError() bool }
+	if !errors.As(err, &retryable) || retryable.RetryableError() {
+		t.Error("expected PinningError to not be retryable")
+	}
+
+	var temporary interface{ Temporary() bool }
+	if !errors.As(err, &temporary) || temporary.Temporary() {
+		t.Error("expected PinningError to not be temporary")
+	}
+}
+
+func newTestCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sts.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %s", err)
+	}
+	return cert
+}
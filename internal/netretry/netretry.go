@@ -0,0 +1,282 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package netretry classifies networking errors that are unlikely to succeed when retried, such as DNS lookup failures,
+// so that retries can be abandoned after a limited number of attempts instead of continuing for many minutes.
+//
+// The same Classifier is used by the AWS SDK for Go v2 retryer wrapper, Retryer, and by the AWS SDK for Go v1 retry handler
+// in awsv1shim.
+package netretry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
+)
+
+// Class is a class of networking error.
+type Class string
+
+const (
+	// ClassNoSuchHost is a DNS lookup failure, e.g. "dial tcp: lookup FQDN: no such host".
+	ClassNoSuchHost Class = "NoSuchHost"
+
+	// ClassConnectionRefused is a refused connection, e.g. "dial tcp IPADDRESS:443: connect: connection refused".
+	ClassConnectionRefused Class = "ConnectionRefused"
+
+	// ClassNetworkUnreachable is a network or host that cannot be reached, e.g. "connect: network is unreachable".
+	ClassNetworkUnreachable Class = "NetworkUnreachable"
+
+	// ClassTimeout is a timeout connecting to a host, e.g. "dial tcp IPADDRESS:443: i/o timeout".
+	ClassTimeout Class = "Timeout"
+
+	// ClassTLSHandshakeTimeout is a timeout during the TLS handshake, e.g. "net/http: TLS handshake timeout".
+	ClassTLSHandshakeTimeout Class = "TLSHandshakeTimeout"
+
+	// ClassCertificate is a TLS certificate verification failure, e.g. "x509: certificate signed by unknown authority".
+	ClassCertificate Class = "Certificate"
+
+	// ClassProxyAuthenticationRequired is a proxy rejecting the CONNECT request with HTTP status 407.
+	ClassProxyAuthenticationRequired Class = "ProxyAuthenticationRequired"
+)
+
+// Classes returns all classes of networking error.
+func Classes() []Class {
+	return []Class{
+		ClassNoSuchHost,
+		ClassConnectionRefused,
+		ClassNetworkUnreachable,
+		ClassTimeout,
+		ClassTLSHandshakeTimeout,
+		ClassCertificate,
+		ClassProxyAuthenticationRequired,
+	}
+}
+
+// DefaultClasses returns the classes of networking error for which retries are abandoned by default.
+func DefaultClasses() []Class {
+	return []Class{
+		ClassNoSuchHost,
+		ClassConnectionRefused,
+	}
+}
+
+// origErrer matches awserr.Error from the AWS SDK for Go v1, which does not support errors.Unwrap
+type origErrer interface {
+	OrigErr() error
+}
+
+// Classify returns the class of the networking error in err's chain, if any.
+// The original errors of AWS SDK for Go v1 awserr.Errors are also examined.
+func Classify(err error) (Class, bool) {
+	for err != nil {
+		if class, ok := classify(err); ok {
+			return class, true
+		}
+		awsErr, ok := err.(origErrer)
+		if !ok {
+			return "", false
+		}
+		err = awsErr.OrigErr()
+	}
+	return "", false
+}
+
+func classify(err error) (Class, bool) {
+	if dnsErr, ok := errs.As[*net.DNSError](err); ok && dnsErr.IsNotFound {
+		return ClassNoSuchHost, true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ClassConnectionRefused, true
+	}
+
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return ClassNetworkUnreachable, true
+	}
+
+	if isCertificateError(err) {
+		return ClassCertificate, true
+	}
+
+	if isProxyAuthenticationRequired(err) {
+		return ClassProxyAuthenticationRequired, true
+	}
+
+	// Not all networking errors wrap typed errors, e.g. those returned by custom dialers
+	if opErr, ok := errs.As[*net.OpError](err); ok {
+		switch message := opErr.Error(); {
+		case strings.Contains(message, "no such host"):
+			return ClassNoSuchHost, true
+		case strings.Contains(message, "connection refused"):
+			return ClassConnectionRefused, true
+		case strings.Contains(message, "network is unreachable"), strings.Contains(message, "no route to host"):
+			return ClassNetworkUnreachable, true
+		}
+	}
+
+	if netErr, ok := errs.As[net.Error](err); ok && netErr.Timeout() {
+		// Timeouts reading a response may succeed on retry, so only connection timeouts are classified
+		if opErr, ok := errs.As[*net.OpError](err); ok && opErr.Op == "dial" && opErr.Timeout() {
+			return ClassTimeout, true
+		}
+		// The net/http TLS handshake timeout error is not exported
+		if strings.HasSuffix(err.Error(), "TLS handshake timeout") {
+			return ClassTLSHandshakeTimeout, true
+		}
+	}
+
+	return "", false
+}
+
+func isCertificateError(err error) bool {
+	if _, ok := errs.As[*tls.CertificateVerificationError](err); ok {
+		return true
+	}
+	if _, ok := errs.As[x509.UnknownAuthorityError](err); ok {
+		return true
+	}
+	if _, ok := errs.As[x509.CertificateInvalidError](err); ok {
+		return true
+	}
+	if _, ok := errs.As[x509.HostnameError](err); ok {
+		return true
+	}
+	return false
+}
+
+// isProxyAuthenticationRequired returns true if err is the error returned by net/http when a proxy responds to a CONNECT
+// request with HTTP status 407. The error is not typed, so only its text can be examined.
+func isProxyAuthenticationRequired(err error) bool {
+	urlErr, ok := errs.As[*url.Error](err)
+	if !ok {
+		return false
+	}
+
+	err = urlErr.Err
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			break
+		}
+		err = inner
+	}
+
+	return err.Error() == http.StatusText(http.StatusProxyAuthRequired)
+}
+
+// Classifier determines when retries of networking errors should be abandoned.
+type Classifier struct {
+	maxRetries int
+	classes    []Class
+}
+
+// NewClassifier returns a Classifier that abandons retries of the given classes of networking error after maxRetries retries.
+// If maxRetries is not positive, constants.MaxNetworkRetryCount is used. If classes is nil, DefaultClasses are used.
+func NewClassifier(maxRetries int, classes []Class) *Classifier {
+	if maxRetries <= 0 {
+		maxRetries = constants.MaxNetworkRetryCount
+	}
+	if classes == nil {
+		classes = DefaultClasses()
+	}
+
+	return &Classifier{
+		maxRetries: maxRetries,
+		classes:    slices.Clone(classes),
+	}
+}
+
+// Abandon returns true, with the class of the networking error, if no further retries should be made after
+// retries retries have failed with err.
+func (c *Classifier) Abandon(retries int, err error) (Class, bool) {
+	if retries < c.maxRetries {
+		return "", false
+	}
+
+	class, ok := Classify(err)
+	if !ok || !slices.Contains(c.classes, class) {
+		return "", false
+	}
+
+	return class, true
+}
+
+// AbandonedError is the error wrapped by the retry.MaxAttemptsError returned by Retryer.RetryDelay when retries of a
+// networking error are abandoned.
+type AbandonedError struct {
+	Class Class
+	Err   error
+}
+
+func (e *AbandonedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AbandonedError) Unwrap() error {
+	return e.Err
+}
+
+// Retryer wraps an AWS SDK for Go v2 retryer to abandon retries of networking errors.
+type Retryer struct {
+	aws.RetryerV2
+
+	classifier *Classifier
+}
+
+var _ aws.RetryerV2 = &Retryer{}
+
+// NewRetryer returns a Retryer wrapping retryer.
+func NewRetryer(retryer aws.Retryer, classifier *Classifier) *Retryer {
+	return &Retryer{
+		RetryerV2:  AsRetryerV2(retryer),
+		classifier: classifier,
+	}
+}
+
+// RetryDelay is used rather than IsErrorRetryable, since this is the only function that takes the attempt count.
+// The class of an abandoned networking error is returned in an AbandonedError, to be logged by the caller.
+func (r *Retryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	if class, ok := r.classifier.Abandon(attempt, err); ok {
+		return 0, &retry.MaxAttemptsError{
+			Attempt: attempt,
+			Err: &AbandonedError{
+				Class: class,
+				Err:   err,
+			},
+		}
+	}
+
+	return r.RetryerV2.RetryDelay(attempt, err)
+}
+
+// AsRetryerV2 returns retryer as an aws.RetryerV2. A retryer that does not implement aws.RetryerV2 acquires its
+// initial token for each attempt, as the AWS SDK for Go v2 retry middleware does.
+func AsRetryerV2(retryer aws.Retryer) aws.RetryerV2 {
+	if v2, ok := retryer.(aws.RetryerV2); ok {
+		return v2
+	}
+	return retryerV2{Retryer: retryer}
+}
+
+// retryerV2 adapts an aws.Retryer that does not implement aws.RetryerV2
+type retryerV2 struct {
+	aws.Retryer
+}
+
+func (r retryerV2) GetAttemptToken(context.Context) (func(error) error, error) {
+	return r.GetInitialToken(), nil
+}
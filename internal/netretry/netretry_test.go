@@ -0,0 +1,349 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package netretry
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+)
+
+// tlsHandshakeTimeoutError is equivalent to the unexported net/http error
+type tlsHandshakeTimeoutError struct{}
+
+func (tlsHandshakeTimeoutError) Timeout() bool   { return true }
+func (tlsHandshakeTimeoutError) Temporary() bool { return true }
+func (tlsHandshakeTimeoutError) Error() string   { return "net/http: TLS handshake timeout" }
+
+// origErrError is equivalent to an awserr.Error from the AWS SDK for Go v1
+type origErrError struct {
+	origErr error
+}
+
+func (e origErrError) Error() string {
+	return "RequestError: send request failed"
+}
+
+func (e origErrError) OrigErr() error {
+	return e.origErr
+}
+
+func urlError(err error) error {
+	return &url.Error{
+		Op:  "Post",
+		URL: "https://sts.us-west-2.amazonaws.com/",
+		Err: err,
+	}
+}
+
+func dialError(err error) error {
+	return urlError(&net.OpError{
+		Op:  "dial",
+		Net: "tcp",
+		Err: err,
+	})
+}
+
+func operationError(err error) error {
+	return &smithy.OperationError{
+		ServiceID:     "STS",
+		OperationName: "GetCallerIdentity",
+		Err:           err,
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testcases := map[string]struct {
+		err           error
+		expectedClass Class
+		expectedOK    bool
+	}{
+		"nil": {
+			err: nil,
+		},
+		"other error": {
+			err: errors.New("test"),
+		},
+		"DNS not found": {
+			err:           dialError(&net.DNSError{Err: "no such host", Name: "sts.example.com", IsNotFound: true}),
+			expectedClass: ClassNoSuchHost,
+			expectedOK:    true,
+		},
+		"DNS temporary": {
+			err: dialError(&net.DNSError{Err: "server misbehaving", Name: "sts.example.com", IsTemporary: true}),
+		},
+		"untyped no such host": {
+			err:           dialError(errors.New("lookup sts.example.com: no such host")),
+			expectedClass: ClassNoSuchHost,
+			expectedOK:    true,
+		},
+		"connection refused": {
+			err:           dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)),
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"untyped connection refused": {
+			err:           dialError(errors.New("connection refused")),
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"network unreachable": {
+			err:           dialError(os.NewSyscallError("connect", syscall.ENETUNREACH)),
+			expectedClass: ClassNetworkUnreachable,
+			expectedOK:    true,
+		},
+		"host unreachable": {
+			err:           dialError(os.NewSyscallError("connect", syscall.EHOSTUNREACH)),
+			expectedClass: ClassNetworkUnreachable,
+			expectedOK:    true,
+		},
+		"i/o timeout": {
+			err:           dialError(os.ErrDeadlineExceeded),
+			expectedClass: ClassTimeout,
+			expectedOK:    true,
+		},
+		"read i/o timeout": {
+			err: urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}),
+		},
+		"TLS handshake timeout": {
+			err:           urlError(tlsHandshakeTimeoutError{}),
+			expectedClass: ClassTLSHandshakeTimeout,
+			expectedOK:    true,
+		},
+		"context deadline exceeded": {
+			err: urlError(context.DeadlineExceeded),
+		},
+		"unknown authority": {
+			err:           urlError(x509.UnknownAuthorityError{}),
+			expectedClass: ClassCertificate,
+			expectedOK:    true,
+		},
+		"hostname": {
+			err:           urlError(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "sts.example.com"}),
+			expectedClass: ClassCertificate,
+			expectedOK:    true,
+		},
+		"certificate invalid": {
+			err:           urlError(x509.CertificateInvalidError{Cert: &x509.Certificate{}, Reason: x509.Expired}),
+			expectedClass: ClassCertificate,
+			expectedOK:    true,
+		},
+		"proxy authentication required": {
+			err:           urlError(errors.New(http.StatusText(http.StatusProxyAuthRequired))),
+			expectedClass: ClassProxyAuthenticationRequired,
+			expectedOK:    true,
+		},
+		"proxy authentication required without url.Error": {
+			err: errors.New(http.StatusText(http.StatusProxyAuthRequired)),
+		},
+		"v2 operation error": {
+			err:           operationError(fmt.Errorf("request send failed, %w", dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)))),
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"v1 error": {
+			err:           origErrError{origErr: dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED))},
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"v1 error other error": {
+			err: origErrError{origErr: errors.New("test")},
+		},
+		"v1 error without original error": {
+			err: origErrError{},
+		},
+	}
+
+	for name, testcase := range testcases {
+		testcase := testcase
+
+		t.Run(name, func(t *testing.T) {
+			class, ok := Classify(testcase.err)
+
+			if a, e := ok, testcase.expectedOK; a != e {
+				t.Errorf("expected %t, got %t", e, a)
+			}
+			if a, e := class, testcase.expectedClass; a != e {
+				t.Errorf("expected class %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestClassifierAbandon(t *testing.T) {
+	refused := dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED))
+
+	testcases := map[string]struct {
+		maxRetries    int
+		classes       []Class
+		retries       int
+		err           error
+		expectedClass Class
+		expectedOK    bool
+	}{
+		"defaults under MaxNetworkRetryCount": {
+			retries: constants.MaxNetworkRetryCount - 1,
+			err:     refused,
+		},
+		"defaults at MaxNetworkRetryCount": {
+			retries:       constants.MaxNetworkRetryCount,
+			err:           refused,
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"defaults class not configured": {
+			retries: constants.MaxNetworkRetryCount,
+			err:     dialError(os.ErrDeadlineExceeded),
+		},
+		"defaults other error": {
+			retries: constants.MaxNetworkRetryCount,
+			err:     errors.New("test"),
+		},
+		"max retries": {
+			maxRetries:    2,
+			retries:       2,
+			err:           refused,
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"class configured": {
+			classes:       []Class{ClassNoSuchHost, ClassConnectionRefused},
+			retries:       constants.MaxNetworkRetryCount,
+			err:           refused,
+			expectedClass: ClassConnectionRefused,
+			expectedOK:    true,
+		},
+		"class not configured": {
+			classes: []Class{ClassNoSuchHost},
+			retries: constants.MaxNetworkRetryCount,
+			err:     refused,
+		},
+		"no classes": {
+			classes: []Class{},
+			retries: constants.MaxNetworkRetryCount,
+			err:     refused,
+		},
+	}
+
+	for name, testcase := range testcases {
+		testcase := testcase
+
+		t.Run(name, func(t *testing.T) {
+			classifier := NewClassifier(testcase.maxRetries, testcase.classes)
+
+			class, ok := classifier.Abandon(testcase.retries, testcase.err)
+
+			if a, e := ok, testcase.expectedOK; a != e {
+				t.Errorf("expected %t, got %t", e, a)
+			}
+			if a, e := class, testcase.expectedClass; a != e {
+				t.Errorf("expected class %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestRetryer(t *testing.T) {
+	refused := operationError(dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)))
+
+	retryer := NewRetryer(retry.NewStandard(func(o *retry.StandardOptions) {
+		o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) {
+			return time.Millisecond, nil
+		})
+	}), NewClassifier(3, nil))
+
+	var _ aws.RetryerV2 = retryer
+
+	if !retryer.IsErrorRetryable(refused) {
+		t.Fatal("expected error to be retryable")
+	}
+
+	delay, err := retryer.RetryDelay(2, refused)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := delay, time.Millisecond; a != e {
+		t.Errorf("expected delay %s, got %s", e, a)
+	}
+
+	_, err = retryer.RetryDelay(3, refused)
+	var maxAttemptsErr *retry.MaxAttemptsError
+	if !errors.As(err, &maxAttemptsErr) {
+		t.Fatalf("expected MaxAttemptsError, got %v", err)
+	}
+	if a, e := maxAttemptsErr.Attempt, 3; a != e {
+		t.Errorf("expected attempt %d, got %d", e, a)
+	}
+	var abandonedErr *AbandonedError
+	if !errors.As(err, &abandonedErr) {
+		t.Fatalf("expected AbandonedError, got %v", err)
+	}
+	if a, e := abandonedErr.Class, ClassConnectionRefused; a != e {
+		t.Errorf("expected class %q, got %q", e, a)
+	}
+	if !errors.Is(err, refused) {
+		t.Errorf("expected original error, got %v", err)
+	}
+
+	if _, err := retryer.RetryDelay(3, errors.New("test")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, err := retryer.GetAttemptToken(context.Background()); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+// retryerV1 is an aws.Retryer that does not implement aws.RetryerV2
+type retryerV1 struct {
+	aws.Retryer
+
+	initialTokens int
+}
+
+func (r *retryerV1) GetInitialToken() func(error) error {
+	r.initialTokens++
+	return r.Retryer.GetInitialToken()
+}
+
+func TestAsRetryerV2(t *testing.T) {
+	standard := retry.NewStandard()
+	if a := AsRetryerV2(standard); a != aws.RetryerV2(standard) {
+		t.Errorf("expected retryer to be returned as is, got %T", a)
+	}
+
+	retryer := &retryerV1{Retryer: standard}
+	v2 := AsRetryerV2(retryer)
+
+	release, err := v2.GetAttemptToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := release(nil); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if a, e := retryer.initialTokens, 1; a != e {
+		t.Errorf("expected %d initial tokens, got %d", e, a)
+	}
+
+	if _, err := NewRetryer(retryer, NewClassifier(0, nil)).GetAttemptToken(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := retryer.initialTokens, 2; a != e {
+		t.Errorf("expected %d initial tokens, got %d", e, a)
+	}
+}
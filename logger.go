@@ -4,19 +4,17 @@
 package awsbase
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/textproto"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -24,6 +22,7 @@ import (
 	smithylogging "github.com/aws/smithy-go/logging"
 	"github.com/aws/smithy-go/middleware"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 	"go.opentelemetry.io/otel/attribute"
@@ -47,7 +46,11 @@ func (l debugLogger) Logf(classification smithylogging.Classification, format st
 		}
 	} else {
 		s = strings.ReplaceAll(s, "\r", "") // Works around https://github.com/jen20/teamcity-go-test/pull/2
-		log.Printf("[%s] missing_context: %s "+string(logging.AwsSdkKey)+"="+awsSdkGoV2Val, classification, s)
+		level := slog.LevelDebug
+		if classification == smithylogging.Warn {
+			level = slog.LevelWarn
+		}
+		logging.MissingContextLogger().Log(context.Background(), level, s, string(logging.AwsSdkKey), awsSdkGoV2Val)
 	}
 }
 
@@ -73,6 +76,15 @@ func (l *logAttributeExtractor) HandleInitialize(ctx context.Context, in middlew
 	out middleware.InitializeOutput, metadata middleware.Metadata, err error) {
 	logger := logging.RetrieveLogger(ctx)
 
+	for _, attribute := range operationAttributes(ctx, in) {
+		ctx = logger.SetField(ctx, string(attribute.Key), attribute.Value.AsInterface())
+	}
+
+	return next.HandleInitialize(ctx, in)
+}
+
+// operationAttributes returns the attributes describing an AWS API operation, used for both log fields and spans.
+func operationAttributes(ctx context.Context, in middleware.InitializeInput) []attribute.KeyValue {
 	region := awsmiddleware.GetRegion(ctx)
 	serviceID := awsmiddleware.GetServiceID(ctx)
 
@@ -91,20 +103,94 @@ func (l *logAttributeExtractor) HandleInitialize(ctx context.Context, in middlew
 	}
 	if setter, ok := setters[serviceID]; ok {
 		attributes = append(attributes, setter(ctx, in)...)
+	} else {
+		attributes = append(attributes, serviceAttributeSetter(ctx, in)...)
 	}
 
-	for _, attribute := range attributes {
-		ctx = logger.SetField(ctx, string(attribute.Key), attribute.Value.AsInterface())
-	}
+	return attributes
+}
 
-	return next.HandleInitialize(ctx, in)
+// serviceAttributeSetter sets the resource identifier attributes for services without a dedicated setter.
+func serviceAttributeSetter(ctx context.Context, in middleware.InitializeInput) []attribute.KeyValue {
+	return logging.ServiceAttributes(awsmiddleware.GetServiceID(ctx), in.Parameters)
 }
 
 // Replaces the built-in logging middleware from https://github.com/aws/smithy-go/blob/main/transport/http/middleware_http_logging.go
 // We want access to the request and response structs, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v2 without forcing us to manually implement
 // configuration that the SDK handles for us.
-type requestResponseLogger struct{}
+type requestResponseLogger struct {
+	// metrics is nil unless a MeterProvider is configured
+	metrics *logging.Metrics
+
+	// policy decides which requests and responses are logged. A nil policy logs everything.
+	policy *logging.LogPolicy
+
+	redactor *logging.Redactor
+
+	// har is nil unless HAR export is configured
+	har *logging.HARWriter
+
+	// clockSkew records the clock skew observed in responses. A nil tracker observes nothing.
+	clockSkew *clockskew.Tracker
+
+	// clockSkewThreshold is the clock skew above which a warning is logged. Zero uses the default threshold.
+	clockSkewThreshold time.Duration
+}
+
+// withRequestResponseLogger adds the request and response logging middleware.
+// When a MeterProvider or HAR export is configured, the middleware also records metrics or HAR entries, even if
+// logging is disabled. The clock skew observed in responses is recorded in clockSkew.
+func withRequestResponseLogger(c *Config, clockSkew *clockskew.Tracker) (func(*middleware.Stack) error, error) {
+	policy, err := logPolicy(c)
+	if err != nil {
+		return nil, fmt.Errorf("creating log policy: %w", err)
+	}
+
+	metrics, err := logging.NewMetrics(c.MeterProvider)
+	if err != nil {
+		return nil, fmt.Errorf("creating metrics instruments: %w", err)
+	}
+
+	var har *logging.HARWriter
+	if c.HAR != nil {
+		har, err = logging.NewHARWriter(c.HAR, c.RedactionRules...)
+		if err != nil {
+			return nil, fmt.Errorf("creating HAR writer: %w", err)
+		}
+	}
+
+	logger := &requestResponseLogger{
+		metrics:            metrics,
+		policy:             policy,
+		redactor:           logging.NewRedactor(c.RedactionRules...),
+		har:                har,
+		clockSkew:          clockSkew,
+		clockSkewThreshold: c.ClockSkewWarningThreshold,
+	}
+
+	return func(stack *middleware.Stack) error {
+		if !logger.policy.Disabled() {
+			if err := addRetryLogger(stack, logger.policy); err != nil {
+				return err
+			}
+		}
+		return stack.Deserialize.Add(logger, middleware.After)
+	}, nil
+}
+
+// logPolicy returns the logging policy for c. SuppressDebugLog turns logging off for all services, including services
+// with an override.
+func logPolicy(c *Config) (*logging.LogPolicy, error) {
+	policy, err := logging.NewLogPolicy(c.LogVerbosity, c.ServiceLogVerbosity, c.LogSampleRate)
+	if err != nil {
+		return nil, err
+	}
+	if c.SuppressDebugLog {
+		return logging.NewLogPolicy(logging.VerbosityOff, nil, 0)
+	}
+	return policy, nil
+}
 
 // ID is the middleware identifier.
 func (r *requestResponseLogger) ID() string {
@@ -133,17 +219,51 @@ func (r *requestResponseLogger) HandleDeserialize(ctx context.Context, in middle
 
 	rc := smithyRequest.Build(ctx)
 
-	requestFields, err := logging.DecomposeHTTPRequest(ctx, rc)
-	if err != nil {
-		return out, metadata, fmt.Errorf("decomposing request: %w", err)
+	serviceID := awsmiddleware.GetServiceID(ctx)
+	verbosity := r.policy.Verbosity(serviceID)
+
+	// Set when the request is logged together with its response
+	var deferredRequestFields map[string]any
+
+	if verbosity != logging.VerbosityOff {
+		requestFields, err := logging.DecomposeHTTPRequest(ctx, rc)
+		if err != nil {
+			return out, metadata, fmt.Errorf("decomposing request: %w", err)
+		}
+		if !r.policy.LogsBodies(serviceID) {
+			delete(requestFields, "http.request.body")
+		}
+		r.redactor.RedactFields(serviceID, awsmiddleware.GetOperationName(ctx), requestFields)
+		if r.policy.DefersRequest(serviceID) {
+			deferredRequestFields = requestFields
+		} else {
+			logger.Debug(ctx, "HTTP Request Sent", requestFields)
+		}
+
+		smithyRequest, err = smithyRequest.SetStream(rc.Body)
+		if err != nil {
+			return out, metadata, err
+		}
+		in.Request = smithyRequest
 	}
-	logger.Debug(ctx, "HTTP Request Sent", requestFields)
 
-	smithyRequest, err = smithyRequest.SetStream(rc.Body)
-	if err != nil {
-		return out, metadata, err
+	var harExchange *logging.HARExchange
+	if r.har != nil {
+		var harErr error
+		harExchange, harErr = r.har.Begin(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), rc)
+		if harErr != nil {
+			logger.Warn(ctx, fmt.Sprintf("recording HAR entry: %s", harErr))
+		}
+
+		smithyRequest, err = smithyRequest.SetStream(rc.Body)
+		if err != nil {
+			return out, metadata, err
+		}
+		in.Request = smithyRequest
 	}
-	in.Request = smithyRequest
+
+	trace := logging.NewConnectionTrace()
+	ctx = logging.WithConnectionTrace(ctx, trace)
 
 	start := time.Now()
 
@@ -151,156 +271,172 @@ func (r *requestResponseLogger) HandleDeserialize(ctx context.Context, in middle
 
 	elapsed := time.Since(start)
 
+	var resp *http.Response
 	if err == nil {
 		smithyResponse, ok := out.RawResponse.(*smithyhttp.Response)
 		if !ok {
 			return out, metadata, fmt.Errorf("unknown response type: %T", out.RawResponse)
 		}
+		resp = smithyResponse.Response
 
-		responseFields, err := decomposeHTTPResponse(ctx, smithyResponse.Response, elapsed)
-		if err != nil {
-			return out, metadata, fmt.Errorf("decomposing response: %w", err)
+		r.observeClockSkew(ctx, resp, start, start.Add(elapsed))
+	}
+
+	if verbosity != logging.VerbosityOff && r.policy.LogsResponse(serviceID, err != nil || resp.StatusCode >= http.StatusBadRequest) {
+		if deferredRequestFields != nil {
+			logger.Debug(ctx, "HTTP Request Sent", deferredRequestFields)
+		}
+		if resp != nil {
+			r.logResponse(ctx, resp, elapsed, trace)
 		}
-		logger.Debug(ctx, "HTTP Response Received", responseFields)
 	}
 
+	if harExchange != nil {
+		r.har.End(ctx, harExchange, resp, err)
+	}
+
+	r.recordMetrics(ctx, rc, resp, elapsed)
+
 	return out, metadata, err
 }
 
-func decomposeHTTPResponse(ctx context.Context, resp *http.Response, elapsed time.Duration) (map[string]any, error) {
-	var attributes []attribute.KeyValue
+// observeClockSkew records the clock skew from the Date header of resp, and logs a warning the first time it exceeds the
+// threshold.
+func (r *requestResponseLogger) observeClockSkew(ctx context.Context, resp *http.Response, sent, received time.Time) {
+	if _, ok := r.clockSkew.Observe(resp, sent, received); !ok {
+		return
+	}
+	if skew, ok := r.clockSkew.Warn(r.clockSkewThreshold); ok {
+		logging.RetrieveLogger(ctx).Warn(ctx, logging.ClockSkewWarning, logging.ClockSkewFields(skew))
+	}
+}
 
-	attributes = append(attributes, attribute.Int64("http.duration", elapsed.Milliseconds()))
+// recordMetrics records the metrics for the current attempt. The response size is recorded once the body has been read.
+// Failing to record metrics does not fail the API call.
+func (r *requestResponseLogger) recordMetrics(ctx context.Context, req *http.Request, resp *http.Response, elapsed time.Duration) {
+	if r.metrics == nil {
+		return
+	}
 
-	attributes = append(attributes, httpconv.ClientResponse(resp)...)
+	attempt := logging.Attempt{
+		ServiceID:    awsmiddleware.GetServiceID(ctx),
+		Operation:    awsmiddleware.GetOperationName(ctx),
+		Region:       awsmiddleware.GetRegion(ctx),
+		Number:       attemptNumber(req),
+		Duration:     elapsed,
+		RequestBytes: req.ContentLength,
+	}
 
-	attributes = append(attributes, logging.DecomposeResponseHeaders(resp)...)
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
 
-	bodyLogger := responseBodyLogger(ctx)
-	err := bodyLogger.Log(ctx, resp, &attributes)
-	if err != nil {
-		return nil, err
+		code, err := logging.ResponseErrorCode(resp)
+		if err != nil {
+			logging.RetrieveLogger(ctx).Warn(ctx, fmt.Sprintf("recording metrics: reading error code: %s", err))
+		}
+		_, throttled := retry.DefaultThrottleErrorCodes[code]
+		attempt.Throttled = throttled || resp.StatusCode == http.StatusTooManyRequests
 	}
 
-	result := make(map[string]any, len(attributes))
-	for _, attribute := range attributes {
-		result[string(attribute.Key)] = attribute.Value.AsInterface()
-	}
+	r.metrics.RecordAttempt(ctx, attempt)
+	r.metrics.CountResponseBytes(ctx, attempt, resp)
+}
 
-	return result, nil
+// attemptNumber returns the attempt number from the "amz-sdk-request" header set by the retry middleware.
+func attemptNumber(req *http.Request) int {
+	attempt, _ := sdkRequestAttempts(req)
+	return attempt
 }
 
-func responseBodyLogger(ctx context.Context) logging.ResponseBodyLogger {
-	if awsmiddleware.GetServiceID(ctx) == "S3" {
-		if op := awsmiddleware.GetOperationName(ctx); op == "GetObject" {
-			return &logging.S3ObjectResponseBodyLogger{}
+// sdkRequestAttempts returns the attempt number and maximum number of attempts from the "amz-sdk-request" header set
+// by the retry middleware, e.g. "attempt=2; max=3". The maximum is zero if not set.
+func sdkRequestAttempts(req *http.Request) (attempt, maxAttempts int) {
+	attempt = 1
+	for _, part := range strings.Split(req.Header.Get("Amz-Sdk-Request"), ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "attempt":
+			attempt = n
+		case "max":
+			maxAttempts = n
 		}
 	}
-
-	return &defaultResponseBodyLogger{}
+	return attempt, maxAttempts
 }
 
-var _ logging.ResponseBodyLogger = &defaultResponseBodyLogger{}
+// logResponse logs the response. Unless the body is streamed to the caller, the response is logged once the deserializer
+// has finished reading the body, using a bounded prefix of the body captured as it was read.
+func (r *requestResponseLogger) logResponse(ctx context.Context, resp *http.Response, elapsed time.Duration, trace *logging.ConnectionTrace) {
+	logger := logging.RetrieveLogger(ctx)
 
-type defaultResponseBodyLogger struct{}
+	attributes := decomposeHTTPResponse(resp, elapsed, trace)
 
-func (l *defaultResponseBodyLogger) Log(ctx context.Context, resp *http.Response, attrs *[]attribute.KeyValue) error {
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+	serviceID, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	rule := logging.DefaultResponseBodyLoggers.Lookup(serviceID, operation, resp)
 
-	// Restore the body reader
-	resp.Body = io.NopCloser(bytes.NewBuffer(content))
+	logBody := r.policy.LogsBodies(serviceID)
 
-	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(content)))
+	log := func(body *logging.BodyCapture) {
+		if logBody {
+			formatted, err := rule.Format(resp, body)
+			if err != nil {
+				logger.Warn(ctx, fmt.Sprintf("decomposing response: %s", err))
+				return
+			}
+			attributes = append(attributes, attribute.String("http.response.body", formatted))
+		}
 
-	body, err := logging.ReadTruncatedBody(reader, logging.MaxResponseBodyLen)
-	if err != nil {
-		return err
+		responseFields := make(map[string]any, len(attributes))
+		for _, attribute := range attributes {
+			responseFields[string(attribute.Key)] = attribute.Value.AsInterface()
+		}
+		r.redactor.RedactFields(serviceID, operation, responseFields)
+		logger.Debug(ctx, "HTTP Response Received", responseFields)
 	}
 
-	*attrs = append(*attrs, attribute.String("http.response.body", body))
+	if rule.Streaming || !logBody {
+		log(nil)
+		return
+	}
 
-	return nil
+	resp.Body = logging.NewBodyCapture(resp.Body, responseBufferLen, log, rule.CaptureOptions()...)
 }
 
-// May be contributed to go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws
-// See: https://github.com/open-telemetry/opentelemetry-go-contrib/issues/4321
-func s3AttributeSetter(ctx context.Context, in middleware.InitializeInput) []attribute.KeyValue {
-	s3Attributes := []attribute.KeyValue{}
-
-	switch v := in.Parameters.(type) {
-	case *s3.AbortMultipartUploadInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3UploadID(aws.ToString(v.UploadId)))
-
-	case *s3.CompleteMultipartUploadInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+func decomposeHTTPResponse(resp *http.Response, elapsed time.Duration, trace *logging.ConnectionTrace) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
 
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
+	attributes = append(attributes, attribute.Int64("http.duration", elapsed.Milliseconds()))
 
-		s3Attributes = append(s3Attributes, semconv.AWSS3UploadID(aws.ToString(v.UploadId)))
+	attributes = append(attributes, trace.Attributes()...)
 
-	case *s3.CreateBucketInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+	attributes = append(attributes, httpconv.ClientResponse(resp)...)
 
-	case *s3.CreateMultipartUploadInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+	attributes = append(attributes, logging.RequestIDAttributes(resp)...)
 
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
+	attributes = append(attributes, logging.DecomposeResponseHeaders(resp)...)
 
-	case *s3.DeleteBucketInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+	return attributes
+}
 
-	case *s3.DeleteObjectInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+// responseBufferLen allows for the truncation marker of logging.ReadTruncatedBody, which truncates at line boundaries
+const responseBufferLen = logging.MaxResponseBodyLen + 1024
 
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
+// May be contributed to go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws
+// See: https://github.com/open-telemetry/opentelemetry-go-contrib/issues/4321
+//
+// The bucket, key, version ID, upload ID, part number and copy source are read from any S3 input that has them,
+// so that new operations are covered without changes here.
+func s3AttributeSetter(ctx context.Context, in middleware.InitializeInput) []attribute.KeyValue {
+	s3Attributes := []attribute.KeyValue{}
 
-	case *s3.DeleteObjectsInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
+	s3Attributes = append(s3Attributes, logging.ServiceAttributes(s3.ServiceID, in.Parameters)...)
 
+	if v, ok := in.Parameters.(*s3.DeleteObjectsInput); ok && v.Delete != nil {
 		s3Attributes = append(s3Attributes, semconv.AWSS3Delete(serializeDeleteShorthand(v.Delete)))
-
-	case *s3.GetObjectInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
-
-	case *s3.HeadBucketInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-	case *s3.HeadObjectInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
-
-	case *s3.ListBucketsInput:
-		// ListBucketsInput defines no attributes
-
-	case *s3.ListObjectsInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-	case *s3.ListObjectsV2Input:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-	case *s3.PutObjectInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
-
-	case *s3.UploadPartInput:
-		s3Attributes = append(s3Attributes, semconv.AWSS3Bucket(aws.ToString(v.Bucket)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3Key(aws.ToString(v.Key)))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3PartNumber(int(aws.ToInt32(v.PartNumber))))
-
-		s3Attributes = append(s3Attributes, semconv.AWSS3UploadID(aws.ToString(v.UploadId)))
 	}
 
 	return s3Attributes
@@ -0,0 +1,625 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+func TestRequestResponseLogger_responseBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	output, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The body is captured while the deserializer reads it, so deserialization must still see the whole body
+	if a, e := aws.ToString(output.Account), "222222222222"; a != e {
+		t.Errorf("expected account %q, got %q", e, a)
+	}
+
+	entries := logger.entries("HTTP Response Received")
+	if a, e := len(entries), 1; a != e {
+		t.Fatalf("expected %d response log entry, got %d", e, a)
+	}
+	body, ok := entries[0]["http.response.body"].(string)
+	if !ok {
+		t.Fatalf("expected response body field, got %v", entries[0])
+	}
+	if !strings.Contains(body, "<Account>222222222222</Account>") {
+		t.Errorf("expected response body to be logged, got %q", body)
+	}
+}
+
+func TestRequestResponseLogger_requestIDs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("X-Amzn-Requestid", "01234567-89ab-cdef-0123-456789abcdef")
+		w.Header().Set("X-Amz-Id-2", "vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw=")
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	if _, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	entries := logger.entries("HTTP Response Received")
+	if a, e := len(entries), 1; a != e {
+		t.Fatalf("expected %d response log entry, got %d", e, a)
+	}
+	if a, e := entries[0]["aws.request_id"], "01234567-89ab-cdef-0123-456789abcdef"; a != e {
+		t.Errorf("expected request ID %q, got %v", e, a)
+	}
+	if a, e := entries[0]["aws.extended_request_id"], "vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw="; a != e {
+		t.Errorf("expected extended request ID %q, got %v", e, a)
+	}
+}
+
+func TestRequestResponseLogger_clockSkew(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("Date", time.Now().Add(-20*time.Minute).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	tracker := &clockskew.Tracker{}
+	withLogger, err := withRequestResponseLogger(&Config{
+		SuppressDebugLog: true,
+	}, tracker)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	for range 2 {
+		if _, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// The warning is logged once, even when other logging is suppressed
+	entries := logger.entries(logging.ClockSkewWarning)
+	if a, e := len(entries), 1; a != e {
+		t.Fatalf("expected %d clock skew warning, got %d", e, a)
+	}
+	skew, _ := entries[0][string(logging.ClockSkewKey)].(int64)
+	if skew > -19*time.Minute.Milliseconds() || skew < -21*time.Minute.Milliseconds() {
+		t.Errorf("expected clock skew of about -20m, got %dms", skew)
+	}
+	if observed, _ := tracker.Skew(); observed > -19*time.Minute || observed < -21*time.Minute {
+		t.Errorf("expected tracked clock skew of about -20m, got %s", observed)
+	}
+}
+
+func TestGetAwsConfig_clockSkewCorrection(t *testing.T) {
+	const skew = -20 * time.Minute
+
+	var mu sync.Mutex
+	var signingTimes []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signingTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Errorf("parsing X-Amz-Date: %s", err)
+		}
+		mu.Lock()
+		signingTimes = append(signingTimes, signingTime)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	newClient := func() *sts.Client {
+		t.Helper()
+
+		_, awsConfig, diags := GetAwsConfig(context.Background(), &Config{
+			AccessKey:           "AKID",
+			SecretKey:           "SECRET",
+			Region:              "us-east-1",
+			SkipCredsValidation: true,
+			CorrectClockSkew:    true,
+		})
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		// The correction applies to all clients created from the configuration, not only to the IAM and STS clients used
+		// for authentication
+		return sts.NewFromConfig(awsConfig, func(o *sts.Options) {
+			o.BaseEndpoint = aws.String(ts.URL)
+		})
+	}
+
+	client := newClient()
+	for range 2 {
+		if _, err := client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// Each configuration has its own clock skew tracker
+	if _, err := newClient().GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	now := time.Now()
+	for i, expected := range []time.Time{now, now.Add(skew), now} {
+		if a, e := signingTimes[i], expected; a.Sub(e).Abs() > 5*time.Second {
+			t.Errorf("request %d: expected signing time %s, got %s", i+1, e, a)
+		}
+	}
+}
+
+func TestRequestResponseLogger_redaction(t *testing.T) {
+	const secretAccessKey = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::555555555555:assumed-role/role/session</Arn>
+      <AssumedRoleId>ARO123EXAMPLE123:session</AssumedRoleId>
+    </AssumedRoleUser>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>` + secretAccessKey + `</SecretAccessKey>
+      <SessionToken>AQoDYXdzEPT//////////wEXAMPLE</SessionToken>
+      <Expiration>2099-12-31T23:59:59Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+  <ResponseMetadata>
+    <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+  </ResponseMetadata>
+</AssumeRoleResponse>`))
+	}))
+	defer ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{
+		RedactionRules: []logging.RedactionRule{
+			{ServiceID: "STS", Operation: "AssumeRole", Fields: []string{"RoleSessionName"}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	output, err := client.AssumeRole(ctx, &sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws:iam::555555555555:role/role"),
+		RoleSessionName: aws.String("session"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Redaction applies to the logged body only
+	if a, e := aws.ToString(output.Credentials.SecretAccessKey), secretAccessKey; a != e {
+		t.Errorf("expected secret access key %q, got %q", e, a)
+	}
+
+	requests := logger.entries("HTTP Request Sent")
+	if a, e := len(requests), 1; a != e {
+		t.Fatalf("expected %d request log entry, got %d", e, a)
+	}
+	requestBody, _ := requests[0]["http.request.body"].(string)
+	if !strings.Contains(requestBody, "RoleSessionName=*****") {
+		t.Errorf("expected RoleSessionName to be redacted from request body, got %q", requestBody)
+	}
+
+	responses := logger.entries("HTTP Response Received")
+	if a, e := len(responses), 1; a != e {
+		t.Fatalf("expected %d response log entry, got %d", e, a)
+	}
+	responseBody, _ := responses[0]["http.response.body"].(string)
+	if strings.Contains(responseBody, secretAccessKey) {
+		t.Errorf("expected secret access key to be redacted from response body, got %q", responseBody)
+	}
+	if !strings.Contains(responseBody, "<SecretAccessKey>*****</SecretAccessKey>") || !strings.Contains(responseBody, "<SessionToken>*****</SessionToken>") {
+		t.Errorf("expected credentials to be redacted from response body, got %q", responseBody)
+	}
+	if !strings.Contains(responseBody, "<AccessKeyId>ASIAEXAMPLE</AccessKeyId>") {
+		t.Errorf("expected access key ID to be logged, got %q", responseBody)
+	}
+}
+
+func TestRequestResponseLogger_har(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+
+	harConfig := &logging.HARConfig{
+		Path: path,
+	}
+	defer harConfig.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{
+		SuppressDebugLog: true,
+		HAR:              harConfig,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", "TOKEN"),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	if _, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Debug logging is suppressed, but HAR entries are still recorded
+	if a, e := len(logger.entries("HTTP Request Sent")), 0; a != e {
+		t.Errorf("expected %d request log entries, got %d", e, a)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading HAR file: %s", err)
+	}
+	var har struct {
+		Log struct {
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					Headers  []struct{ Name, Value string }
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+				Operation string `json:"_awsOperation"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(b, &har); err != nil {
+		t.Fatalf("parsing HAR file: %s", err)
+	}
+
+	if a, e := len(har.Log.Entries), 1; a != e {
+		t.Fatalf("expected %d HAR entry, got %d", e, a)
+	}
+	entry := har.Log.Entries[0]
+
+	if a, e := entry.Operation, "GetCallerIdentity"; a != e {
+		t.Errorf("expected operation %q, got %q", e, a)
+	}
+	if a, e := entry.Request.PostData.Text, "Action=GetCallerIdentity&Version=2011-06-15"; a != e {
+		t.Errorf("expected request body %q, got %q", e, a)
+	}
+	for _, header := range entry.Request.Headers {
+		if (header.Name == "Authorization" || header.Name == "X-Amz-Security-Token") && header.Value != "*****" {
+			t.Errorf("expected header %q to be masked, got %q", header.Name, header.Value)
+		}
+	}
+	if a, e := entry.Response.Status, http.StatusOK; a != e {
+		t.Errorf("expected status %d, got %d", e, a)
+	}
+	if !strings.Contains(entry.Response.Content.Text, "<Account>222222222222</Account>") {
+		t.Errorf("expected response body to be recorded, got %q", entry.Response.Content.Text)
+	}
+}
+
+func TestRequestResponseLogger_invalidVerbosity(t *testing.T) {
+	_, err := withRequestResponseLogger(&Config{
+		ServiceLogVerbosity: map[string]logging.Verbosity{"STS": "debug"},
+	}, nil)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if a, e := err.Error(), `creating log policy: service "STS": invalid log verbosity "debug", expected one of "full", "headers", "errors" or "off"`; a != e {
+		t.Errorf("expected error %q, got %q", e, a)
+	}
+}
+
+func TestRequestResponseLogger_verbosity(t *testing.T) {
+	const errorResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>ValidationError</Code>
+    <Message>invalid request</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`
+
+	testCases := map[string]struct {
+		config Config
+
+		// statuses are the status codes returned for consecutive calls
+		statuses []int
+
+		expectedRequests  int
+		expectedResponses int
+		expectedBodies    bool
+	}{
+		"default": {
+			statuses:          []int{http.StatusOK, http.StatusBadRequest},
+			expectedRequests:  2,
+			expectedResponses: 2,
+			expectedBodies:    true,
+		},
+		"SuppressDebugLog": {
+			config:   Config{SuppressDebugLog: true},
+			statuses: []int{http.StatusOK, http.StatusBadRequest},
+		},
+		"off": {
+			config:   Config{LogVerbosity: logging.VerbosityOff},
+			statuses: []int{http.StatusOK, http.StatusBadRequest},
+		},
+		"headers": {
+			config:            Config{LogVerbosity: logging.VerbosityHeaders},
+			statuses:          []int{http.StatusOK, http.StatusBadRequest},
+			expectedRequests:  2,
+			expectedResponses: 2,
+		},
+		"errors": {
+			config:            Config{LogVerbosity: logging.VerbosityErrors},
+			statuses:          []int{http.StatusOK, http.StatusOK, http.StatusBadRequest},
+			expectedRequests:  1,
+			expectedResponses: 1,
+			expectedBodies:    true,
+		},
+		"service override": {
+			config: Config{
+				LogVerbosity:        logging.VerbosityOff,
+				ServiceLogVerbosity: map[string]logging.Verbosity{"STS": logging.VerbosityHeaders},
+			},
+			statuses:          []int{http.StatusOK},
+			expectedRequests:  1,
+			expectedResponses: 1,
+		},
+		"SuppressDebugLog with service override": {
+			config: Config{
+				SuppressDebugLog:    true,
+				ServiceLogVerbosity: map[string]logging.Verbosity{"STS": logging.VerbosityHeaders},
+			},
+			statuses: []int{http.StatusOK},
+		},
+		"other service override": {
+			config: Config{
+				ServiceLogVerbosity: map[string]logging.Verbosity{"IAM": logging.VerbosityOff},
+			},
+			statuses:          []int{http.StatusOK},
+			expectedRequests:  1,
+			expectedResponses: 1,
+			expectedBodies:    true,
+		},
+		"sampled": {
+			config:            Config{LogSampleRate: 3},
+			statuses:          []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest},
+			expectedRequests:  3,
+			expectedResponses: 3,
+			expectedBodies:    true,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			statuses := testcase.statuses
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := statuses[0]
+				statuses = statuses[1:]
+				mu.Unlock()
+
+				w.Header().Set("Content-Type", "text/xml")
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(getCallerIdentityResponse))
+				} else {
+					_, _ = w.Write([]byte(errorResponse))
+				}
+			}))
+			defer ts.Close()
+
+			withLogger, err := withRequestResponseLogger(&testcase.config, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			client := sts.New(sts.Options{
+				Region:       "us-east-1",
+				BaseEndpoint: aws.String(ts.URL),
+				Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+				APIOptions: []func(*middleware.Stack) error{
+					withLogger,
+				},
+			})
+
+			logger := &recordingLogger{}
+			ctx := logging.RegisterLogger(context.Background(), logger)
+
+			for range testcase.statuses {
+				_, _ = client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+			}
+
+			requests := logger.entries("HTTP Request Sent")
+			if a, e := len(requests), testcase.expectedRequests; a != e {
+				t.Errorf("expected %d request log entries, got %d", e, a)
+			}
+			responses := logger.entries("HTTP Response Received")
+			if a, e := len(responses), testcase.expectedResponses; a != e {
+				t.Errorf("expected %d response log entries, got %d", e, a)
+			}
+
+			for _, fields := range requests {
+				if _, ok := fields["http.request.body"]; ok != testcase.expectedBodies {
+					t.Errorf("expected request body logged to be %t, got %t", testcase.expectedBodies, ok)
+				}
+			}
+			for _, fields := range responses {
+				if _, ok := fields["http.response.body"]; ok != testcase.expectedBodies {
+					t.Errorf("expected response body logged to be %t, got %t", testcase.expectedBodies, ok)
+				}
+			}
+		})
+	}
+}
+
+type recordingLogger struct {
+	logging.NullLogger
+
+	mu  sync.Mutex
+	log []recordedEntry
+}
+
+type recordedEntry struct {
+	msg    string
+	fields map[string]any
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...map[string]any) {
+	l.record(ctx, msg, fields)
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...map[string]any) {
+	l.record(ctx, msg, fields)
+}
+
+type recordingLoggerFieldsKeyT string
+
+const recordingLoggerFieldsKey recordingLoggerFieldsKeyT = "recording-logger-fields"
+
+func (l *recordingLogger) SetField(ctx context.Context, key string, value any) context.Context {
+	fields := maps.Clone(recordedFields(ctx))
+	if fields == nil {
+		fields = make(map[string]any)
+	}
+	fields[key] = value
+	return context.WithValue(ctx, recordingLoggerFieldsKey, fields)
+}
+
+func recordedFields(ctx context.Context) map[string]any {
+	fields, _ := ctx.Value(recordingLoggerFieldsKey).(map[string]any)
+	return fields
+}
+
+func (l *recordingLogger) record(ctx context.Context, msg string, fields []map[string]any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := recordedEntry{msg: msg, fields: maps.Clone(recordedFields(ctx))}
+	if entry.fields == nil {
+		entry.fields = make(map[string]any)
+	}
+	for _, f := range fields {
+		for k, v := range f {
+			entry.fields[k] = v
+		}
+	}
+	l.log = append(l.log, entry)
+}
+
+func (l *recordingLogger) entries(msg string) []map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result []map[string]any
+	for _, entry := range l.log {
+		if entry.msg == msg {
+			result = append(result, entry.fields)
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"sync"
+)
+
+// BodyCapture wraps an HTTP body and keeps a copy of at most limit bytes of it as it is read,
+// so that the body can be logged without buffering it in full.
+// The done function is called once, when the body is closed or has been read to the end.
+type BodyCapture struct {
+	body  io.ReadCloser
+	limit int
+	done  func(*BodyCapture)
+
+	buf  bytes.Buffer
+	n    int64
+	hash hash.Hash
+	once sync.Once
+}
+
+var _ io.ReadCloser = &BodyCapture{}
+
+// BodyCaptureOption configures a BodyCapture.
+type BodyCaptureOption func(*BodyCapture)
+
+// WithSHA256 computes the SHA-256 digest of the whole body as it is read.
+func WithSHA256() BodyCaptureOption {
+	return func(c *BodyCapture) {
+		c.hash = sha256.New()
+	}
+}
+
+// NewBodyCapture wraps body, keeping a copy of at most limit bytes.
+func NewBodyCapture(body io.ReadCloser, limit int, done func(*BodyCapture), optFns ...BodyCaptureOption) *BodyCapture {
+	c := &BodyCapture{
+		body:  body,
+		limit: limit,
+		done:  done,
+	}
+	for _, optFn := range optFns {
+		optFn(c)
+	}
+	return c
+}
+
+func (c *BodyCapture) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	if n > 0 {
+		c.n += int64(n)
+		if remaining := c.limit - c.buf.Len(); remaining > 0 {
+			c.buf.Write(p[:min(n, remaining)])
+		}
+		if c.hash != nil {
+			c.hash.Write(p[:n])
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *BodyCapture) Close() error {
+	err := c.body.Close()
+	c.finish()
+	return err
+}
+
+func (c *BodyCapture) finish() {
+	c.once.Do(func() {
+		if c.done != nil {
+			c.done(c)
+		}
+	})
+}
+
+// Bytes returns the captured prefix of the body.
+func (c *BodyCapture) Bytes() []byte {
+	return c.buf.Bytes()
+}
+
+// Len returns the number of bytes read from the body, including any that were not captured.
+func (c *BodyCapture) Len() int64 {
+	return c.n
+}
+
+// SHA256 returns the SHA-256 digest of the bytes read from the body, or nil if WithSHA256 was not used.
+func (c *BodyCapture) SHA256() []byte {
+	if c.hash == nil {
+		return nil
+	}
+	return c.hash.Sum(nil)
+}
+
+// Truncated reports whether more of the body was read than was captured.
+func (c *BodyCapture) Truncated() bool {
+	return c.n > int64(c.buf.Len())
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBodyCapture(t *testing.T) {
+	testcases := map[string]struct {
+		body              string
+		limit             int
+		expectedCaptured  string
+		expectedTruncated bool
+	}{
+		"under limit": {
+			body:             "abcdef",
+			limit:            10,
+			expectedCaptured: "abcdef",
+		},
+		"at limit": {
+			body:             "abcdefghij",
+			limit:            10,
+			expectedCaptured: "abcdefghij",
+		},
+		"over limit": {
+			body:              "abcdefghijklmnop",
+			limit:             10,
+			expectedCaptured:  "abcdefghij",
+			expectedTruncated: true,
+		},
+		"empty": {
+			body:  "",
+			limit: 10,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			var captured string
+			body := NewBodyCapture(io.NopCloser(strings.NewReader(testcase.body)), testcase.limit, func(c *BodyCapture) {
+				calls++
+				captured = string(c.Bytes())
+			})
+
+			// Read in small chunks, as a streaming deserializer would
+			var read bytes.Buffer
+			if _, err := io.CopyBuffer(&read, struct{ io.Reader }{body}, make([]byte, 3)); err != nil {
+				t.Fatalf("reading body: %s", err)
+			}
+			if err := body.Close(); err != nil {
+				t.Fatalf("closing body: %s", err)
+			}
+
+			if a, e := read.String(), testcase.body; a != e {
+				t.Errorf("expected body %q to be passed through, got %q", e, a)
+			}
+			if a, e := calls, 1; a != e {
+				t.Errorf("expected done to be called %d time, got %d", e, a)
+			}
+			if a, e := captured, testcase.expectedCaptured; a != e {
+				t.Errorf("expected captured %q, got %q", e, a)
+			}
+			if a, e := body.Len(), int64(len(testcase.body)); a != e {
+				t.Errorf("expected length %d, got %d", e, a)
+			}
+			if a, e := body.Truncated(), testcase.expectedTruncated; a != e {
+				t.Errorf("expected truncated %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestBodyCapture_closeBeforeEOF(t *testing.T) {
+	var captured string
+	body := NewBodyCapture(io.NopCloser(strings.NewReader("abcdefghij")), 100, func(c *BodyCapture) {
+		captured = string(c.Bytes())
+	})
+
+	buf := make([]byte, 4)
+	if _, err := body.Read(buf); err != nil {
+		t.Fatalf("reading body: %s", err)
+	}
+	if captured != "" {
+		t.Fatalf("expected done not to be called before close, captured %q", captured)
+	}
+
+	if err := body.Close(); err != nil {
+		t.Fatalf("closing body: %s", err)
+	}
+	if a, e := captured, "abcd"; a != e {
+		t.Errorf("expected captured %q, got %q", e, a)
+	}
+}
+
+var benchmarkBodySizes = []int{
+	16 * 1024,        // 16 KiB
+	1024 * 1024,      // 1 MiB
+	16 * 1024 * 1024, // 16 MiB, e.g. a large DescribeInstances response
+}
+
+// BenchmarkResponseBody_readAll measures buffering the whole body for logging, as was done before BodyCapture.
+func BenchmarkResponseBody_readAll(b *testing.B) {
+	for _, size := range benchmarkBodySizes {
+		content := bytes.Repeat([]byte("x"), size)
+
+		b.Run(byteSizeName(size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+
+			for b.Loop() {
+				body, err := io.ReadAll(bytes.NewReader(content))
+				if err != nil {
+					b.Fatal(err)
+				}
+				logged := body[:min(len(body), MaxResponseBodyLen)]
+
+				// The deserializer then reads the restored body
+				if _, err := io.Copy(io.Discard, bytes.NewReader(body)); err != nil {
+					b.Fatal(err)
+				}
+				_ = logged
+			}
+		})
+	}
+}
+
+// BenchmarkResponseBody_capture measures capturing a bounded prefix while the deserializer reads the body.
+func BenchmarkResponseBody_capture(b *testing.B) {
+	for _, size := range benchmarkBodySizes {
+		content := bytes.Repeat([]byte("x"), size)
+
+		b.Run(byteSizeName(size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+
+			for b.Loop() {
+				var logged []byte
+				body := NewBodyCapture(io.NopCloser(bytes.NewReader(content)), MaxResponseBodyLen, func(c *BodyCapture) {
+					logged = c.Bytes()
+				})
+
+				if _, err := io.Copy(io.Discard, body); err != nil {
+					b.Fatal(err)
+				}
+				body.Close()
+				_ = logged
+			}
+		})
+	}
+}
+
+func byteSizeName(size int) string {
+	if size >= 1024*1024 {
+		return fmt.Sprintf("%dMiB", size/(1024*1024))
+	}
+	return fmt.Sprintf("%dKiB", size/1024)
+}
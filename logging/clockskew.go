@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ClockSkewKey attribute.Key = "tf_aws.clock_skew" // milliseconds, positive if the local clock is behind
+)
+
+// ClockSkewWarning is logged when the local clock differs from the clock of an AWS service by more than the configured
+// threshold.
+const ClockSkewWarning = "Clock skew detected: the local clock differs from AWS, which may cause request signatures to be rejected"
+
+// ClockSkewFields returns the log fields for a clock skew warning.
+func ClockSkewFields(skew time.Duration) map[string]any {
+	return map[string]any{
+		string(ClockSkewKey): skew.Milliseconds(),
+	}
+}
@@ -0,0 +1,513 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxHARBodyLen is the maximum length of a request or response body recorded in a HAR file
+const maxHARBodyLen = 1024 * 1024
+
+// HARConfig configures the export of AWS API traffic to a HAR (HTTP Archive) 1.2 file.
+// The file is opened by the first writer created from the HARConfig and shared by all of its writers, so that AWS SDK
+// for Go v1 and v2 clients record to a single archive. Close closes the file once the clients are no longer used.
+type HARConfig struct {
+	// Path is the path of the HAR file. The file is truncated if it exists.
+	Path string
+
+	// MaskHeaders lists additional request and response headers whose values are masked.
+	// Authorization, security token and customer-provided encryption key headers are always masked.
+	MaskHeaders []string
+
+	// RedactionRules are applied to recorded bodies in addition to the built-in rules.
+	RedactionRules []RedactionRule
+
+	// OmitBodies records only the size and type of request and response bodies.
+	OmitBodies bool
+
+	mu     sync.Mutex
+	file   *harFile
+	closed bool
+}
+
+// open returns the HAR file, creating it the first time.
+func (c *HARConfig) open() (*harFile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, errors.New("opening HAR file: already closed")
+	}
+	if c.file == nil {
+		file, err := createHARFile(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		c.file = file
+	}
+
+	return c.file, nil
+}
+
+// Close closes the HAR file. Entries for responses whose bodies are read afterwards are not recorded.
+func (c *HARConfig) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if c.file == nil {
+		return nil
+	}
+	return c.file.close()
+}
+
+// harMaskedHeaders are always masked
+var harMaskedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Amz-Security-Token",
+	"X-Amz-Server-Side-Encryption-Customer-Key",
+	"X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key",
+}
+
+// harMaskedQueryParameters are always masked, e.g. in presigned URLs
+var harMaskedQueryParameters = []string{
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+}
+
+// HARWriter records requests and responses to a HAR file. It is safe for concurrent use.
+type HARWriter struct {
+	file        *harFile
+	maskHeaders []string
+	redactor    *Redactor
+	omitBodies  bool
+}
+
+// NewHARWriter returns a writer for c.Path. Bodies are redacted using the built-in rules, rules and c.RedactionRules.
+func NewHARWriter(c *HARConfig, rules ...RedactionRule) (*HARWriter, error) {
+	file, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+
+	maskHeaders := slices.Clone(harMaskedHeaders)
+	for _, header := range c.MaskHeaders {
+		maskHeaders = append(maskHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	return &HARWriter{
+		file:        file,
+		maskHeaders: maskHeaders,
+		redactor:    NewRedactor(slices.Concat(rules, c.RedactionRules)...),
+		omitBodies:  c.OmitBodies,
+	}, nil
+}
+
+// HARExchange is a request in progress, recorded once its response has been read.
+type HARExchange struct {
+	serviceID   string
+	operation   string
+	started     time.Time
+	request     *http.Request
+	requestBody []byte
+	wait        time.Duration
+}
+
+// Begin starts recording req. Up to maxHARBodyLen bytes of the request body are read, and req.Body is replaced
+// with a body that returns the whole original body, even if Begin returns an error.
+func (w *HARWriter) Begin(serviceID, operation string, req *http.Request) (*HARExchange, error) {
+	x := &HARExchange{
+		serviceID: serviceID,
+		operation: operation,
+		started:   time.Now(),
+		request:   req,
+	}
+
+	if !w.omitBodies && !harOmitRequestBody(serviceID, operation) {
+		body, err := peekRequestBody(req, maxHARBodyLen)
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		x.requestBody = body
+	}
+
+	return x, nil
+}
+
+// End completes recording the exchange. If resp is not nil, its body is replaced and the entry is written once the
+// body has been read or closed. Otherwise, the entry is written immediately, with err.
+// Errors writing the file are logged as warnings.
+func (w *HARWriter) End(ctx context.Context, x *HARExchange, resp *http.Response, err error) {
+	x.wait = time.Since(x.started)
+
+	write := func(body *BodyCapture) {
+		if writeErr := w.write(x, resp, body, err); writeErr != nil {
+			RetrieveLogger(ctx).Warn(ctx, fmt.Sprintf("writing HAR entry: %s", writeErr))
+		}
+	}
+
+	if resp == nil || resp.Body == nil || w.omitBodies || DefaultResponseBodyLoggers.Lookup(x.serviceID, x.operation, resp).Streaming {
+		write(nil)
+		return
+	}
+
+	resp.Body = NewBodyCapture(resp.Body, maxHARBodyLen, write)
+}
+
+func (w *HARWriter) write(x *HARExchange, resp *http.Response, body *BodyCapture, err error) error {
+	receive := time.Since(x.started) - x.wait
+
+	entry := harEntry{
+		StartedDateTime: x.started.Format(time.RFC3339Nano),
+		Time:            harMilliseconds(x.wait + receive),
+		Request:         w.harRequest(x),
+		Response:        w.harResponse(x, resp, body),
+		Timings: harTimings{
+			Send:    0,
+			Wait:    harMilliseconds(x.wait),
+			Receive: harMilliseconds(receive),
+		},
+		ServiceID: x.serviceID,
+		Operation: x.operation,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	return w.file.write(entry)
+}
+
+func (w *HARWriter) harRequest(x *HARExchange) harRequest {
+	req := x.request
+
+	u := *req.URL
+	query := u.Query()
+	for _, name := range harMaskedQueryParameters {
+		if query.Has(name) {
+			query.Set(name, redactedValue)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	result := harRequest{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     w.harHeaders(req.Header),
+		QueryString: harQueryString(query),
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	if result.HTTPVersion == "" {
+		result.HTTPVersion = "HTTP/1.1"
+	}
+
+	if req.ContentLength != 0 || x.requestBody != nil {
+		mimeType := req.Header.Get("Content-Type")
+		text, _, comment := w.harBody(x, x.requestBody, req.ContentLength)
+		result.PostData = &harPostData{
+			MimeType: mimeType,
+			Params:   []harNameValue{},
+			Text:     text,
+			Comment:  comment,
+		}
+	}
+
+	return result
+}
+
+func (w *HARWriter) harResponse(x *HARExchange, resp *http.Response, body *BodyCapture) harResponse {
+	result := harResponse{
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+		Content: harContent{
+			Size: -1,
+		},
+	}
+	if resp == nil {
+		return result
+	}
+
+	result.Status = resp.StatusCode
+	result.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode)))
+	result.HTTPVersion = resp.Proto
+	result.Headers = w.harHeaders(resp.Header)
+	result.RedirectURL = resp.Header.Get("Location")
+	result.Content.MimeType = resp.Header.Get("Content-Type")
+
+	size := resp.ContentLength
+	var captured []byte
+	if body != nil {
+		size = body.Len()
+		captured = body.Bytes()
+	}
+	result.BodySize = size
+	result.Content.Size = size
+	result.Content.Text, result.Content.Encoding, result.Content.Comment = w.harBody(x, captured, size)
+
+	return result
+}
+
+// harBody returns the text and encoding of a body, redacted and with sensitive values masked.
+// Bodies that are not valid UTF-8 are base64 encoded.
+func (w *HARWriter) harBody(x *HARExchange, body []byte, size int64) (text, encoding, comment string) {
+	if body == nil {
+		if size != 0 {
+			comment = "body not recorded"
+		}
+		return "", "", comment
+	}
+
+	if int64(len(body)) < size {
+		comment = fmt.Sprintf("truncated to %d of %d bytes", len(body), size)
+	}
+
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64", comment
+	}
+
+	text = w.redactor.Redact(x.serviceID, x.operation, string(body))
+	text = MaskAWSSensitiveValues(text)
+
+	return text, "", comment
+}
+
+func (w *HARWriter) harHeaders(header http.Header) []harNameValue {
+	result := []harNameValue{}
+
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			if slices.Contains(w.maskHeaders, http.CanonicalHeaderKey(name)) {
+				value = redactedValue
+			}
+			result = append(result, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return result
+}
+
+func harQueryString(query url.Values) []harNameValue {
+	result := []harNameValue{}
+
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range query[name] {
+			result = append(result, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return result
+}
+
+// harOmitRequestBody reports whether the request body is an S3 object, which is not read.
+func harOmitRequestBody(serviceID, operation string) bool {
+	return serviceID == "S3" && (operation == "PutObject" || operation == "UploadPart")
+}
+
+// peekRequestBody returns up to limit bytes of the request body, replacing req.Body with a body that returns the
+// whole original body.
+func peekRequestBody(req *http.Request, limit int) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)))
+	if err == nil && len(prefix) < limit {
+		// The whole body has been read
+		err = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(prefix))
+	} else {
+		// On error, the request is sent with what was read, and fails the same way
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{
+			Reader: io.MultiReader(bytes.NewReader(prefix), req.Body),
+			Closer: req.Body,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return prefix, nil
+}
+
+func harMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harFile is a HAR file shared by all writers of a HARConfig.
+// After each entry, the file is terminated so that it is always a valid HAR document.
+type harFile struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries int
+	closed  bool
+}
+
+const harTrailer = "]}}\n"
+
+func createHARFile(path string) (*harFile, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening HAR file: %w", err)
+	}
+
+	header, err := json.Marshal(harCreator{Name: "aws-sdk-go-base", Version: "v2"})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, `{"log":{"version":"1.2","creator":%s,"entries":[`, header); err != nil {
+		return nil, fmt.Errorf("writing HAR file: %w", err)
+	}
+
+	file := &harFile{f: f}
+	if err := file.terminate(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (h *harFile) write(entry harEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return errors.New("writing HAR file: already closed")
+	}
+	if h.entries > 0 {
+		b = append([]byte{','}, b...)
+	}
+	if _, err := h.f.Write(b); err != nil {
+		return fmt.Errorf("writing HAR file: %w", err)
+	}
+	h.entries++
+
+	return h.terminate()
+}
+
+func (h *harFile) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	if err := h.f.Close(); err != nil {
+		return fmt.Errorf("closing HAR file: %w", err)
+	}
+	return nil
+}
+
+// terminate writes the end of the document, leaving the offset before it for the next entry.
+func (h *harFile) terminate() error {
+	if _, err := io.WriteString(h.f, harTrailer); err != nil {
+		return fmt.Errorf("writing HAR file: %w", err)
+	}
+	if _, err := h.f.Seek(-int64(len(harTrailer)), io.SeekCurrent); err != nil {
+		return fmt.Errorf("writing HAR file: %w", err)
+	}
+	return nil
+}
+
+// HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/.
+// Fields starting with "_" are custom fields.
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServiceID       string      `json:"_awsServiceId,omitempty"`
+	Operation       string      `json:"_awsOperation,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []harNameValue `json:"params"`
+	Text     string         `json:"text"`
+	Comment  string         `json:"comment,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
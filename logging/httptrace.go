@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ConnectionReusedKey        attribute.Key = "http.connection.reused"
+	GetConnDurationKey         attribute.Key = "http.duration.get_conn"
+	DNSDurationKey             attribute.Key = "http.duration.dns"
+	ConnectDurationKey         attribute.Key = "http.duration.connect"
+	TLSHandshakeDurationKey    attribute.Key = "http.duration.tls_handshake"
+	TimeToFirstByteDurationKey attribute.Key = "http.duration.time_to_first_byte"
+	ProxyKey                   attribute.Key = "http.proxy"
+)
+
+// ProxyDirect is logged as the proxy when a request is sent without one.
+const ProxyDirect = "DIRECT"
+
+// ConnectionTrace records connection-level timings for a single HTTP request attempt.
+// The hooks may be called concurrently, e.g. when dialing several addresses at once.
+type ConnectionTrace struct {
+	mu sync.Mutex
+
+	getConnStart time.Time
+	getConnDone  time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+
+	gotConn bool
+	reused  bool
+
+	proxy string
+}
+
+func NewConnectionTrace() *ConnectionTrace {
+	return &ConnectionTrace{}
+}
+
+type connectionTraceKeyT string
+
+const connectionTraceKey connectionTraceKeyT = "connection-trace"
+
+// WithConnectionTrace returns a context that records the connection timings of HTTP requests made with it to trace.
+func WithConnectionTrace(ctx context.Context, trace *ConnectionTrace) context.Context {
+	ctx = context.WithValue(ctx, connectionTraceKey, trace)
+	return httptrace.WithClientTrace(ctx, trace.clientTrace())
+}
+
+// ConnectionTraceFromContext returns the ConnectionTrace registered with WithConnectionTrace, or nil.
+func ConnectionTraceFromContext(ctx context.Context) *ConnectionTrace {
+	trace, _ := ctx.Value(connectionTraceKey).(*ConnectionTrace)
+	return trace
+}
+
+func (t *ConnectionTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.record(func() {
+				t.getConnStart = time.Now()
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() {
+				t.getConnDone = time.Now()
+				t.gotConn = true
+				t.reused = info.Reused
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() {
+				t.dnsStart = time.Now()
+			})
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() {
+				t.dnsDone = time.Now()
+			})
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() {
+				// Only the first of several parallel dials marks the start
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				return
+			}
+			t.record(func() {
+				t.connectDone = time.Now()
+			})
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() {
+				t.tlsStart = time.Now()
+			})
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() {
+				t.tlsDone = time.Now()
+			})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(func() {
+				t.wroteRequest = time.Now()
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() {
+				t.firstByte = time.Now()
+			})
+		},
+	}
+}
+
+// SetProxy records the proxy chosen for the request, or ProxyDirect if proxy is nil.
+// Any credentials in the proxy URL are not recorded.
+func (t *ConnectionTrace) SetProxy(proxy *url.URL) {
+	v := ProxyDirect
+	if proxy != nil {
+		u := *proxy
+		u.User = nil
+		v = u.String()
+	}
+	t.record(func() {
+		t.proxy = v
+	})
+}
+
+// Reset clears the recorded timings and proxy, so that the trace can be reused for another attempt of the same request.
+func (t *ConnectionTrace) Reset() {
+	t.record(func() {
+		t.getConnStart = time.Time{}
+		t.getConnDone = time.Time{}
+		t.dnsStart = time.Time{}
+		t.dnsDone = time.Time{}
+		t.connectStart = time.Time{}
+		t.connectDone = time.Time{}
+		t.tlsStart = time.Time{}
+		t.tlsDone = time.Time{}
+		t.wroteRequest = time.Time{}
+		t.firstByte = time.Time{}
+		t.gotConn = false
+		t.reused = false
+		t.proxy = ""
+	})
+}
+
+func (t *ConnectionTrace) record(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f()
+}
+
+// Attributes returns the recorded timings, in milliseconds, whether a pooled connection was reused, and the chosen proxy.
+// Phases that did not occur, such as DNS resolution on a reused connection, are omitted.
+func (t *ConnectionTrace) Attributes() []attribute.KeyValue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var attributes []attribute.KeyValue
+
+	if t.gotConn {
+		attributes = append(attributes, ConnectionReusedKey.Bool(t.reused))
+	}
+
+	if t.proxy != "" {
+		attributes = append(attributes, ProxyKey.String(t.proxy))
+	}
+
+	durations := []struct {
+		key        attribute.Key
+		start, end time.Time
+	}{
+		{GetConnDurationKey, t.getConnStart, t.getConnDone},
+		{DNSDurationKey, t.dnsStart, t.dnsDone},
+		{ConnectDurationKey, t.connectStart, t.connectDone},
+		{TLSHandshakeDurationKey, t.tlsStart, t.tlsDone},
+		{TimeToFirstByteDurationKey, t.wroteRequest, t.firstByte},
+	}
+	for _, d := range durations {
+		if d.start.IsZero() || d.end.IsZero() {
+			continue
+		}
+		attributes = append(attributes, d.key.Int64(d.end.Sub(d.start).Milliseconds()))
+	}
+
+	return attributes
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestConnectionTrace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+
+	first := traceRequest(t, client, ts.URL)
+
+	if v, ok := first[ConnectionReusedKey]; !ok {
+		t.Errorf("expected %q attribute", ConnectionReusedKey)
+	} else if v.AsBool() {
+		t.Errorf("expected first connection to be new")
+	}
+	for _, key := range []attribute.Key{GetConnDurationKey, ConnectDurationKey, TimeToFirstByteDurationKey} {
+		if _, ok := first[key]; !ok {
+			t.Errorf("expected %q attribute", key)
+		}
+	}
+	if _, ok := first[TLSHandshakeDurationKey]; ok {
+		t.Errorf("did not expect %q attribute for plain HTTP", TLSHandshakeDurationKey)
+	}
+
+	second := traceRequest(t, client, ts.URL)
+
+	if v := second[ConnectionReusedKey]; !v.AsBool() {
+		t.Errorf("expected second connection to be reused")
+	}
+	if _, ok := second[ConnectDurationKey]; ok {
+		t.Errorf("did not expect %q attribute for reused connection", ConnectDurationKey)
+	}
+}
+
+func TestConnectionTraceFromContext(t *testing.T) {
+	ctx := context.Background()
+
+	if trace := ConnectionTraceFromContext(ctx); trace != nil {
+		t.Errorf("expected no trace, got %v", trace)
+	}
+
+	trace := NewConnectionTrace()
+	ctx = WithConnectionTrace(ctx, trace)
+
+	if a, e := ConnectionTraceFromContext(ctx), trace; a != e {
+		t.Errorf("expected trace %p, got %p", e, a)
+	}
+}
+
+func TestConnectionTraceReset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+
+	trace := NewConnectionTrace()
+	ctx := WithConnectionTrace(context.Background(), trace)
+
+	sendRequest(t, client, ctx, ts.URL)
+	if _, ok := traceAttributes(trace)[ConnectDurationKey]; !ok {
+		t.Fatalf("expected %q attribute", ConnectDurationKey)
+	}
+
+	trace.Reset()
+	if a := trace.Attributes(); len(a) != 0 {
+		t.Errorf("expected no attributes after reset, got %v", a)
+	}
+
+	sendRequest(t, client, ctx, ts.URL)
+	second := traceAttributes(trace)
+	if v := second[ConnectionReusedKey]; !v.AsBool() {
+		t.Errorf("expected second connection to be reused")
+	}
+	if _, ok := second[ConnectDurationKey]; ok {
+		t.Errorf("did not expect %q attribute for reused connection", ConnectDurationKey)
+	}
+}
+
+func traceRequest(t *testing.T, client *http.Client, url string) map[attribute.Key]attribute.Value {
+	t.Helper()
+
+	trace := NewConnectionTrace()
+	ctx := WithConnectionTrace(context.Background(), trace)
+
+	sendRequest(t, client, ctx, url)
+
+	return traceAttributes(trace)
+}
+
+func sendRequest(t *testing.T, client *http.Client, ctx context.Context, url string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("creating request: %s", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %s", err)
+	}
+	// Drain the body so that the connection is returned to the pool
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func traceAttributes(trace *ConnectionTrace) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value)
+	for _, attr := range trace.Attributes() {
+		result[attr.Key] = attr.Value
+	}
+	return result
+}
@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// MeterName is the instrumentation scope name used for metrics recorded by both AWS SDK for Go v1 and v2 clients.
+const MeterName = "github.com/hashicorp/aws-sdk-go-base/v2"
+
+// Metrics records usage metrics for AWS API calls. A nil *Metrics records nothing.
+type Metrics struct {
+	calls         metric.Int64Counter
+	retries       metric.Int64Counter
+	throttles     metric.Int64Counter
+	duration      metric.Float64Histogram
+	requestBytes  metric.Int64Counter
+	responseBytes metric.Int64Counter
+}
+
+// NewMetrics creates the instruments used for AWS API calls. If mp is nil, it returns nil.
+func NewMetrics(mp metric.MeterProvider) (*Metrics, error) {
+	if mp == nil {
+		return nil, nil
+	}
+
+	meter := mp.Meter(MeterName)
+
+	var m Metrics
+	var errs []error
+	var err error
+
+	m.calls, err = meter.Int64Counter("aws.api.calls",
+		metric.WithDescription("Number of AWS API operations called, excluding retries"),
+		metric.WithUnit("{call}"),
+	)
+	errs = append(errs, err)
+
+	m.retries, err = meter.Int64Counter("aws.api.retries",
+		metric.WithDescription("Number of HTTP attempts retrying an AWS API operation"),
+		metric.WithUnit("{attempt}"),
+	)
+	errs = append(errs, err)
+
+	m.throttles, err = meter.Int64Counter("aws.api.throttles",
+		metric.WithDescription("Number of HTTP attempts rejected with a throttling error"),
+		metric.WithUnit("{attempt}"),
+	)
+	errs = append(errs, err)
+
+	m.duration, err = meter.Float64Histogram("aws.api.attempt.duration",
+		metric.WithDescription("Duration of each HTTP attempt of an AWS API operation"),
+		metric.WithUnit("s"),
+	)
+	errs = append(errs, err)
+
+	m.requestBytes, err = meter.Int64Counter("aws.api.request.size",
+		metric.WithDescription("Number of request body bytes sent, as reported by Content-Length"),
+		metric.WithUnit("By"),
+	)
+	errs = append(errs, err)
+
+	m.responseBytes, err = meter.Int64Counter("aws.api.response.size",
+		metric.WithDescription("Number of response body bytes read"),
+		metric.WithUnit("By"),
+	)
+	errs = append(errs, err)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Attempt describes a single HTTP attempt of an AWS API operation.
+type Attempt struct {
+	ServiceID string
+	Operation string
+	Region    string
+
+	// Number is the 1-based attempt number. Attempts after the first are retries.
+	Number int
+
+	Duration time.Duration
+
+	// StatusCode is 0 if no response was received.
+	StatusCode int
+
+	Throttled bool
+
+	// RequestBytes is negative if unknown.
+	RequestBytes int64
+}
+
+// RecordAttempt records the metrics for a single HTTP attempt, except the response size, which is recorded by
+// CountResponseBytes.
+func (m *Metrics) RecordAttempt(ctx context.Context, a Attempt) {
+	if m == nil {
+		return
+	}
+
+	opt := a.measurementOption()
+
+	if a.Number > 1 {
+		m.retries.Add(ctx, 1, opt)
+	} else {
+		m.calls.Add(ctx, 1, opt)
+	}
+	if a.Throttled {
+		m.throttles.Add(ctx, 1, opt)
+	}
+	m.duration.Record(ctx, a.Duration.Seconds(), opt)
+	if a.RequestBytes > 0 {
+		m.requestBytes.Add(ctx, a.RequestBytes, opt)
+	}
+}
+
+// CountResponseBytes wraps the body of resp to record the number of bytes read from it for the attempt a, once the
+// body has been read to the end or closed. Unlike Content-Length, this also covers chunked responses.
+func (m *Metrics) CountResponseBytes(ctx context.Context, a Attempt, resp *http.Response) {
+	if m == nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	opt := a.measurementOption()
+	resp.Body = NewBodyCapture(resp.Body, 0, func(body *BodyCapture) {
+		if n := body.Len(); n > 0 {
+			m.responseBytes.Add(ctx, n, opt)
+		}
+	})
+}
+
+func (a Attempt) measurementOption() metric.MeasurementOption {
+	// Only low-cardinality attributes are used, so that e.g. S3 object keys do not create new time series
+	attributes := []attribute.KeyValue{
+		otelaws.ServiceAttr(a.ServiceID),
+		otelaws.OperationAttr(a.Operation),
+		otelaws.RegionAttr(a.Region),
+	}
+	if a.StatusCode != 0 {
+		attributes = append(attributes, semconv.HTTPStatusCode(a.StatusCode))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(attributes...))
+}
+
+// maxErrorCodeBodyLen bounds the prefix of an error response body read to find the error code.
+// AWS error responses are small, and the code appears near the start.
+const maxErrorCodeBodyLen = 64 * 1024
+
+// ResponseErrorCode returns the AWS error code of an error response, e.g. "ThrottlingException",
+// or an empty string if resp is not an error response or no code can be found.
+// At most the first 64 KiB of the response body are read, and the body is restored.
+func ResponseErrorCode(resp *http.Response) (string, error) {
+	if resp.StatusCode < http.StatusBadRequest {
+		return "", nil
+	}
+
+	// Set by services using the JSON protocols, e.g. "ThrottlingException:http://internal.amazon.com/coral/..."
+	if v := resp.Header.Get("X-Amzn-ErrorType"); v != "" {
+		code, _, _ := strings.Cut(v, ":")
+		return code, nil
+	}
+
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return "", nil
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorCodeBodyLen))
+
+	// Restore the body reader, including any part that was not read
+	resp.Body = &prefixedBody{
+		Reader: io.MultiReader(bytes.NewReader(content), resp.Body),
+		Closer: resp.Body,
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("{")) {
+		return jsonErrorCode(content), nil
+	}
+	return xmlErrorCode(content), nil
+}
+
+// prefixedBody is a response body whose prefix has already been read
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func jsonErrorCode(content []byte) string {
+	var body struct {
+		Type string `json:"__type"`
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(content, &body); err != nil {
+		return ""
+	}
+
+	code := body.Type
+	if code == "" {
+		code = body.Code
+	}
+	// e.g. "com.amazonaws.dynamodb.v20120810#ThrottlingException"
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	return code
+}
+
+func xmlErrorCode(content []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "Code" {
+			var code string
+			if err := decoder.DecodeElement(&code, &start); err != nil {
+				return ""
+			}
+			return strings.TrimSpace(code)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestResponseErrorCode(t *testing.T) {
+	testcases := map[string]struct {
+		statusCode int
+		header     http.Header
+		body       string
+		expected   string
+	}{
+		"success": {
+			statusCode: http.StatusOK,
+			body:       `<Code>Throttling</Code>`,
+		},
+		"error type header": {
+			statusCode: http.StatusBadRequest,
+			header:     http.Header{"X-Amzn-Errortype": []string{"ThrottlingException:http://internal.amazon.com/coral/com.amazon.coral.availability/"}},
+			body:       `{}`,
+			expected:   "ThrottlingException",
+		},
+		"JSON type": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"Rate exceeded"}`,
+			expected:   "ProvisionedThroughputExceededException",
+		},
+		"JSON code": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"code":"TooManyRequestsException"}`,
+			expected:   "TooManyRequestsException",
+		},
+		"query XML": {
+			statusCode: http.StatusBadRequest,
+			body:       `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error></ErrorResponse>`,
+			expected:   "Throttling",
+		},
+		"REST XML": {
+			statusCode: http.StatusServiceUnavailable,
+			body:       `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`,
+			expected:   "SlowDown",
+		},
+		"empty body": {
+			statusCode: http.StatusForbidden,
+		},
+		"not parsable": {
+			statusCode: http.StatusBadGateway,
+			body:       `Bad Gateway`,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			header := testcase.header
+			if header == nil {
+				header = http.Header{}
+			}
+			resp := &http.Response{
+				StatusCode: testcase.statusCode,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(testcase.body)),
+			}
+
+			code, err := ResponseErrorCode(resp)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if code != testcase.expected {
+				t.Errorf("expected code %q, got %q", testcase.expected, code)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("reading body: %s", err)
+			}
+			if string(body) != testcase.body {
+				t.Errorf("expected body to be restored as %q, got %q", testcase.body, string(body))
+			}
+		})
+	}
+}
+
+func TestResponseErrorCode_largeBody(t *testing.T) {
+	body := `<Error><Code>SlowDown</Code><Message>` + strings.Repeat("x", 2*maxErrorCodeBodyLen) + `</Message></Error>`
+	resp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+
+	// The code is found in the prefix read, and the whole body is restored
+	code, err := ResponseErrorCode(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := code, "SlowDown"; a != e {
+		t.Errorf("expected code %q, got %q", e, a)
+	}
+
+	restored, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %s", err)
+	}
+	if a, e := len(restored), len(body); a != e {
+		t.Errorf("expected restored body of %d bytes, got %d", e, a)
+	}
+}
+
+func TestResponseErrorCode_readError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{},
+		Body:       io.NopCloser(iotest.ErrReader(errors.New("connection reset"))),
+	}
+
+	if _, err := ResponseErrorCode(resp); err == nil {
+		t.Fatal("expected error, got none")
+	}
+}
+
+func TestMetrics_nil(t *testing.T) {
+	m, err := NewMetrics(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m != nil {
+		t.Fatalf("expected nil Metrics, got %v", m)
+	}
+
+	// Recording with a nil *Metrics is a no-op
+	m.RecordAttempt(context.Background(), Attempt{Number: 1})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const redactedValue = "*****"
+
+// RedactionRule lists fields whose values are redacted from the logged request and response bodies of matching operations.
+// Empty ServiceID and Operation match any value.
+// Fields are matched by name against JSON object keys, XML element names and query string parameters, including the
+// last component of flattened parameter names such as "Parameters.member.1.Value". Only scalar JSON values are redacted.
+type RedactionRule struct {
+	ServiceID string
+	Operation string
+	Fields    []string
+}
+
+func (r RedactionRule) matches(serviceID, operation string) bool {
+	return (r.ServiceID == "" || r.ServiceID == serviceID) && (r.Operation == "" || r.Operation == operation)
+}
+
+// defaultRedactionRules cover operations that send or return secrets, credentials or decrypted data
+var defaultRedactionRules = []RedactionRule{
+	{ServiceID: "IAM", Fields: []string{"SecretAccessKey", "Password", "OldPassword", "NewPassword", "PrivateKey"}},
+	{ServiceID: "KMS", Fields: []string{"Plaintext"}},
+	{ServiceID: "Secrets Manager", Fields: []string{"SecretString", "SecretBinary"}},
+	{ServiceID: "SSM", Operation: "GetParameter", Fields: []string{"Value"}},
+	{ServiceID: "SSM", Operation: "GetParameters", Fields: []string{"Value"}},
+	{ServiceID: "SSM", Operation: "GetParametersByPath", Fields: []string{"Value"}},
+	{ServiceID: "SSM", Operation: "GetParameterHistory", Fields: []string{"Value"}},
+	{ServiceID: "SSM", Operation: "PutParameter", Fields: []string{"Value"}},
+	{ServiceID: "STS", Fields: []string{"SecretAccessKey", "SessionToken"}},
+}
+
+type compiledRedactionRule struct {
+	RedactionRule
+
+	json, xml, query *regexp.Regexp
+}
+
+func compileRedactionRule(rule RedactionRule) compiledRedactionRule {
+	names := make([]string, len(rule.Fields))
+	for i, field := range rule.Fields {
+		names[i] = regexp.QuoteMeta(field)
+	}
+	alternation := "(?:" + strings.Join(names, "|") + ")"
+
+	// Values may be cut off, as bodies are truncated before they are logged
+	return compiledRedactionRule{
+		RedactionRule: rule,
+		json:          regexp.MustCompile(`("` + alternation + `"\s*:\s*)(?:"(?:[^"\\]|\\.)*"?|[^\s,{}\[\]]+)`),
+		xml:           regexp.MustCompile(`(<` + alternation + `>)[^<]*`),
+		query:         regexp.MustCompile(`((?:^|&|\n)(?:[^=&\n]*\.)?` + alternation + `=)[^&\n]*`),
+	}
+}
+
+func (r compiledRedactionRule) redact(body string) string {
+	body = r.json.ReplaceAllString(body, `${1}"`+redactedValue+`"`)
+	body = r.xml.ReplaceAllString(body, "${1}"+redactedValue)
+	body = r.query.ReplaceAllString(body, "${1}"+redactedValue)
+	return body
+}
+
+// Redactor redacts sensitive values from logged HTTP bodies. It is safe for concurrent use.
+type Redactor struct {
+	rules []compiledRedactionRule
+}
+
+// NewRedactor returns a Redactor applying the built-in rules and rules.
+func NewRedactor(rules ...RedactionRule) *Redactor {
+	r := &Redactor{}
+
+	for _, rule := range slices.Concat(defaultRedactionRules, rules) {
+		if len(rule.Fields) == 0 {
+			continue
+		}
+		r.rules = append(r.rules, compileRedactionRule(rule))
+	}
+
+	return r
+}
+
+// defaultRedactor is used by a nil Redactor
+var defaultRedactor = NewRedactor()
+
+// Redact returns body with the values of the fields of all matching rules replaced.
+// A nil Redactor applies the built-in rules.
+func (r *Redactor) Redact(serviceID, operation, body string) string {
+	if r == nil {
+		r = defaultRedactor
+	}
+	for _, rule := range r.rules {
+		if rule.matches(serviceID, operation) {
+			body = rule.redact(body)
+		}
+	}
+	return body
+}
+
+// RedactFields redacts the "http.request.body" and "http.response.body" log fields, if present.
+func (r *Redactor) RedactFields(serviceID, operation string, fields map[string]any) {
+	for _, key := range []string{"http.request.body", "http.response.body"} {
+		if body, ok := fields[key].(string); ok {
+			fields[key] = r.Redact(serviceID, operation, body)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	testcases := map[string]struct {
+		serviceID string
+		operation string
+		rules     []RedactionRule
+		body      string
+		expected  string
+	}{
+		"Secrets Manager GetSecretValue": {
+			serviceID: "Secrets Manager",
+			operation: "GetSecretValue",
+			body:      `{"ARN":"arn:aws:secretsmanager:us-east-1:123456789012:secret:test","Name":"test","SecretString":"{\"password\":\"hunter2\"}"}`,
+			expected:  `{"ARN":"arn:aws:secretsmanager:us-east-1:123456789012:secret:test","Name":"test","SecretString":"*****"}`,
+		},
+		"SSM GetParameter pretty-printed": {
+			serviceID: "SSM",
+			operation: "GetParameter",
+			body:      "{\n  \"Parameter\": {\n    \"Name\": \"/test\",\n    \"Type\": \"SecureString\",\n    \"Value\": \"hunter2\"\n  }\n}",
+			expected:  "{\n  \"Parameter\": {\n    \"Name\": \"/test\",\n    \"Type\": \"SecureString\",\n    \"Value\": \"*****\"\n  }\n}",
+		},
+		"SSM AddTagsToResource": {
+			serviceID: "SSM",
+			operation: "AddTagsToResource",
+			body:      `{"Tags":[{"Key":"Name","Value":"test"}]}`,
+			expected:  `{"Tags":[{"Key":"Name","Value":"test"}]}`,
+		},
+		"KMS Decrypt": {
+			serviceID: "KMS",
+			operation: "Decrypt",
+			body:      `{"KeyId":"arn:aws:kms:us-east-1:123456789012:key/test","Plaintext":"aHVudGVyMg=="}`,
+			expected:  `{"KeyId":"arn:aws:kms:us-east-1:123456789012:key/test","Plaintext":"*****"}`,
+		},
+		"STS AssumeRole": {
+			serviceID: "STS",
+			operation: "AssumeRole",
+			body: `<Credentials>
+  <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+  <SecretAccessKey>wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY</SecretAccessKey>
+  <SessionToken>AQoDYXdzEPT//////////wEXAMPLE</SessionToken>
+</Credentials>`,
+			expected: `<Credentials>
+  <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+  <SecretAccessKey>*****</SecretAccessKey>
+  <SessionToken>*****</SessionToken>
+</Credentials>`,
+		},
+		"IAM CreateAccessKey truncated": {
+			serviceID: "IAM",
+			operation: "CreateAccessKey",
+			body:      `<AccessKey><UserName>test</UserName><SecretAccessKey>wJalrXUtnFEMI`,
+			expected:  `<AccessKey><UserName>test</UserName><SecretAccessKey>*****`,
+		},
+		"IAM ChangePassword query": {
+			serviceID: "IAM",
+			operation: "ChangePassword",
+			body:      `Action=ChangePassword&NewPassword=correct-horse&OldPassword=hunter2&Version=2010-05-08`,
+			expected:  `Action=ChangePassword&NewPassword=*****&OldPassword=*****&Version=2010-05-08`,
+		},
+		"JSON truncated string": {
+			serviceID: "Secrets Manager",
+			operation: "PutSecretValue",
+			body:      `{"SecretId":"test","SecretString":"hunter`,
+			expected:  `{"SecretId":"test","SecretString":"*****"`,
+		},
+		"other service": {
+			serviceID: "Lambda",
+			operation: "GetFunction",
+			body:      `{"SecretString":"not a secret"}`,
+			expected:  `{"SecretString":"not a secret"}`,
+		},
+		"custom rule": {
+			serviceID: "Lambda",
+			operation: "GetFunction",
+			rules: []RedactionRule{
+				{ServiceID: "Lambda", Operation: "GetFunction", Fields: []string{"Variables"}},
+				{ServiceID: "Lambda", Fields: []string{"DB_PASSWORD"}},
+			},
+			body:     `{"Configuration":{"Environment":{"Variables":{"DB_PASSWORD":"hunter2","DB_PORT":5432}}}}`,
+			expected: `{"Configuration":{"Environment":{"Variables":{"DB_PASSWORD":"*****","DB_PORT":5432}}}}`,
+		},
+		"custom rule non-string value": {
+			serviceID: "RDS",
+			operation: "CreateDBInstance",
+			rules: []RedactionRule{
+				{ServiceID: "RDS", Fields: []string{"Port", "MasterUserPassword"}},
+			},
+			body:     `{"Port":5432,"MasterUserPassword":"hunter2"}`,
+			expected: `{"Port":"*****","MasterUserPassword":"*****"}`,
+		},
+		"custom rule flattened query parameter": {
+			serviceID: "RDS",
+			operation: "ModifyDBCluster",
+			rules: []RedactionRule{
+				{ServiceID: "RDS", Fields: []string{"MasterUserPassword"}},
+			},
+			body:     "Action=ModifyDBCluster&Options.member.1.MasterUserPassword=hunter2\n",
+			expected: "Action=ModifyDBCluster&Options.member.1.MasterUserPassword=*****\n",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			redactor := NewRedactor(testcase.rules...)
+
+			actual := redactor.Redact(testcase.serviceID, testcase.operation, testcase.body)
+
+			if actual != testcase.expected {
+				t.Errorf("expected\n%s\ngot\n%s", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRedactorRedactFields(t *testing.T) {
+	redactor := NewRedactor()
+
+	fields := map[string]any{
+		"http.request.body":  `{"SecretId":"test","SecretString":"hunter2"}`,
+		"http.response.body": `{"Name":"test","SecretString":"hunter2"}`,
+		"http.status_code":   200,
+	}
+
+	redactor.RedactFields("Secrets Manager", "PutSecretValue", fields)
+
+	if a, e := fields["http.request.body"], `{"SecretId":"test","SecretString":"*****"}`; a != e {
+		t.Errorf("expected request body %q, got %q", e, a)
+	}
+	if a, e := fields["http.response.body"], `{"Name":"test","SecretString":"*****"}`; a != e {
+		t.Errorf("expected response body %q, got %q", e, a)
+	}
+	if a, e := fields["http.status_code"], 200; a != e {
+		t.Errorf("expected status code %v, got %v", e, a)
+	}
+}
+
+func TestRedactor_nil(t *testing.T) {
+	var redactor *Redactor
+
+	actual := redactor.Redact("KMS", "Decrypt", `{"Plaintext":"aHVudGVyMg=="}`)
+
+	if e := `{"Plaintext":"*****"}`; actual != e {
+		t.Errorf("expected %q, got %q", e, actual)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	RequestIDKey         attribute.Key = "aws.request_id"
+	ExtendedRequestIDKey attribute.Key = "aws.extended_request_id" // S3 only
+)
+
+// RequestIDs returns the AWS request ID and the S3 extended request ID, also known as the host ID, from response
+// headers. S3 returns the request ID in "x-amz-request-id" rather than "x-amzn-RequestId".
+func RequestIDs(header http.Header) (requestID, extendedRequestID string) {
+	requestID = header.Get("X-Amzn-Requestid")
+	if requestID == "" {
+		requestID = header.Get("X-Amz-Request-Id")
+	}
+	extendedRequestID = header.Get("X-Amz-Id-2")
+
+	return requestID, extendedRequestID
+}
+
+// RequestIDAttributes returns the request ID attributes for a response. IDs not returned by the service are omitted.
+func RequestIDAttributes(resp *http.Response) []attribute.KeyValue {
+	requestID, extendedRequestID := RequestIDs(resp.Header)
+
+	var attributes []attribute.KeyValue
+	if requestID != "" {
+		attributes = append(attributes, RequestIDKey.String(requestID))
+	}
+	if extendedRequestID != "" {
+		attributes = append(attributes, ExtendedRequestIDKey.String(extendedRequestID))
+	}
+	return attributes
+}
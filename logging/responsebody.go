@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// ResponseBodyFormatter formats a response body as the "http.response.body" log field.
+// body is nil for streaming rules, which are formatted as soon as the response is received.
+type ResponseBodyFormatter func(resp *http.Response, body *BodyCapture) (string, error)
+
+// ResponseBodyRule selects how response bodies are logged.
+// Empty ServiceID, Operation and ContentType match any value. ContentType is a media type, e.g. "application/json",
+// and may end in "*" to match a prefix.
+type ResponseBodyRule struct {
+	ServiceID   string
+	Operation   string
+	ContentType string
+
+	// Streaming rules do not read the body. They are used for operations whose body is streamed to the caller,
+	// such as S3 GetObject, where waiting for the body to be read would delay the log indefinitely.
+	Streaming bool
+
+	// Hash computes the SHA-256 digest of the whole body, available from BodyCapture.SHA256.
+	Hash bool
+
+	Format ResponseBodyFormatter
+}
+
+func (r ResponseBodyRule) matches(serviceID, operation, contentType string) bool {
+	if r.ServiceID != "" && r.ServiceID != serviceID {
+		return false
+	}
+	if r.Operation != "" && r.Operation != operation {
+		return false
+	}
+	if r.ContentType != "" {
+		if prefix, ok := strings.CutSuffix(r.ContentType, "*"); ok {
+			return strings.HasPrefix(contentType, prefix)
+		}
+		return r.ContentType == contentType
+	}
+	return true
+}
+
+// specificity orders matching rules: service and operation take precedence over Content-Type.
+func (r ResponseBodyRule) specificity() int {
+	var n int
+	if r.ServiceID != "" {
+		n += 4
+	}
+	if r.Operation != "" {
+		n += 2
+	}
+	if r.ContentType != "" {
+		n++
+	}
+	return n
+}
+
+// CaptureOptions returns the BodyCapture options needed by the rule.
+func (r ResponseBodyRule) CaptureOptions() []BodyCaptureOption {
+	if r.Hash {
+		return []BodyCaptureOption{WithSHA256()}
+	}
+	return nil
+}
+
+// ResponseBodyLoggers is a registry of response body rules. It is safe for concurrent use.
+type ResponseBodyLoggers struct {
+	mu    sync.RWMutex
+	rules []ResponseBodyRule
+}
+
+// DefaultResponseBodyLoggers is used by both AWS SDK for Go v1 and v2 clients.
+var DefaultResponseBodyLoggers = NewResponseBodyLoggers()
+
+// NewResponseBodyLoggers returns a registry containing the built-in rules.
+func NewResponseBodyLoggers() *ResponseBodyLoggers {
+	r := &ResponseBodyLoggers{}
+
+	// S3 objects are streamed to the caller and may be arbitrarily large
+	r.Register(ResponseBodyRule{ServiceID: "S3", Operation: "GetObject", Streaming: true, Format: FormatRedactedBody})
+	r.Register(ResponseBodyRule{ServiceID: "S3", Operation: "GetObjectTorrent", Streaming: true, Format: FormatRedactedBody})
+
+	// Payloads are opaque or consist mostly of encoded data
+	r.Register(ResponseBodyRule{ServiceID: "Lambda", Operation: "Invoke", Hash: true, Format: FormatBinaryBody})
+	r.Register(ResponseBodyRule{ServiceID: "Kinesis", Operation: "GetRecords", Hash: true, Format: FormatBinaryBody})
+	r.Register(ResponseBodyRule{ServiceID: "ECR", Operation: "GetDownloadUrlForLayer", Hash: true, Format: FormatBinaryBody})
+
+	r.Register(ResponseBodyRule{ContentType: "application/octet-stream", Hash: true, Format: FormatBinaryBody})
+	r.Register(ResponseBodyRule{ContentType: "application/x-amz-cbor-*", Hash: true, Format: FormatBinaryBody})
+	r.Register(ResponseBodyRule{ContentType: "application/vnd.amazon.eventstream", Format: FormatEventStreamBody})
+	r.Register(ResponseBodyRule{ContentType: "application/json", Format: FormatJSONBody})
+	r.Register(ResponseBodyRule{ContentType: "application/x-amz-json-*", Format: FormatJSONBody})
+
+	return r
+}
+
+// Register adds a rule. A rule takes precedence over less specific rules, and over equally specific rules registered earlier.
+func (r *ResponseBodyLoggers) Register(rule ResponseBodyRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rules = append(r.rules, rule)
+}
+
+// Lookup returns the rule for a response. If no rule matches, the body is logged as truncated text.
+func (r *ResponseBodyLoggers) Lookup(serviceID, operation string, resp *http.Response) ResponseBodyRule {
+	contentType := responseMediaType(resp)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := ResponseBodyRule{Format: FormatTextBody}
+	best := -1
+	for _, rule := range r.rules {
+		if !rule.matches(serviceID, operation, contentType) {
+			continue
+		}
+		if n := rule.specificity(); n >= best {
+			result, best = rule, n
+		}
+	}
+
+	return result
+}
+
+func responseMediaType(resp *http.Response) string {
+	v := resp.Header.Get("Content-Type")
+	if v == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		return strings.ToLower(v)
+	}
+	return mediaType
+}
+
+// FormatTextBody logs the body as text, truncated to MaxResponseBodyLen.
+// Only likely AWS access and secret keys are masked; redaction rules are applied by the caller to the logged field.
+func FormatTextBody(_ *http.Response, body *BodyCapture) (string, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(body.Bytes())))
+
+	return ReadTruncatedBody(reader, MaxResponseBodyLen)
+}
+
+// FormatRedactedBody logs only the length and type of the body.
+func FormatRedactedBody(resp *http.Response, _ *BodyCapture) (string, error) {
+	return s3BodyRedacted(resp.ContentLength, resp.Header.Get("Content-Type")), nil
+}
+
+// FormatBinaryBody logs the size and, if the rule hashes the body, the SHA-256 digest of the body.
+func FormatBinaryBody(resp *http.Response, body *BodyCapture) (string, error) {
+	s := fmt.Sprintf("[Binary: %s", formatByteSize(body.Len()))
+
+	if digest := body.SHA256(); digest != nil {
+		s += fmt.Sprintf(", SHA-256: %s", hex.EncodeToString(digest))
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		s += fmt.Sprintf(", Type: %s", contentType)
+	}
+
+	s += "]"
+
+	return s, nil
+}
+
+// FormatJSONBody pretty-prints a JSON body. Bodies that were truncated or are not valid JSON are logged as text.
+func FormatJSONBody(resp *http.Response, body *BodyCapture) (string, error) {
+	if body.Truncated() {
+		return FormatTextBody(resp, body)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body.Bytes(), "", "  "); err != nil {
+		return FormatTextBody(resp, body)
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(&buf))
+
+	return ReadTruncatedBody(reader, MaxResponseBodyLen)
+}
+
+// FormatEventStreamBody summarizes the messages of an event stream (application/vnd.amazon.eventstream) body,
+// without logging their payloads.
+func FormatEventStreamBody(_ *http.Response, body *BodyCapture) (string, error) {
+	var builder strings.Builder
+
+	content := body.Bytes()
+	var count int
+	for len(content) > 0 {
+		message, rest, ok := parseEventStreamMessage(content)
+		if !ok {
+			break
+		}
+		count++
+		content = rest
+
+		fmt.Fprintf(&builder, "\n%s", message)
+	}
+
+	summary := fmt.Sprintf("[Event stream: %d messages, %s", count, formatByteSize(body.Len()))
+	if body.Truncated() || len(content) > 0 {
+		summary += ", truncated"
+	}
+	summary += "]"
+
+	return summary + builder.String(), nil
+}
+
+// eventStreamPreludeLen is the length of the total length, headers length and prelude CRC fields.
+// Each message ends with a 4-byte message CRC.
+const (
+	eventStreamPreludeLen = 12
+	eventStreamCRCLen     = 4
+)
+
+type eventStreamMessage struct {
+	messageType string
+	eventType   string
+	payloadLen  int
+}
+
+func (m eventStreamMessage) String() string {
+	name := m.eventType
+	if name == "" {
+		name = "-"
+	}
+	return fmt.Sprintf("  %s %s (%s)", m.messageType, name, formatByteSize(int64(m.payloadLen)))
+}
+
+// parseEventStreamMessage parses the headers of the first message in b. CRCs are not validated.
+func parseEventStreamMessage(b []byte) (eventStreamMessage, []byte, bool) {
+	var message eventStreamMessage
+
+	if len(b) < eventStreamPreludeLen {
+		return message, nil, false
+	}
+	totalLen := int(binary.BigEndian.Uint32(b[0:4]))
+	headersLen := int(binary.BigEndian.Uint32(b[4:8]))
+	if totalLen > len(b) || eventStreamPreludeLen+headersLen+eventStreamCRCLen > totalLen {
+		return message, nil, false
+	}
+
+	headers := b[eventStreamPreludeLen : eventStreamPreludeLen+headersLen]
+	for len(headers) > 0 {
+		name, value, rest, ok := parseEventStreamHeader(headers)
+		if !ok {
+			return message, nil, false
+		}
+		headers = rest
+
+		switch name {
+		case ":message-type":
+			message.messageType = value
+		case ":event-type", ":exception-type":
+			message.eventType = value
+		}
+	}
+	message.payloadLen = totalLen - eventStreamPreludeLen - headersLen - eventStreamCRCLen
+
+	return message, b[totalLen:], true
+}
+
+// eventStreamHeaderValueLens are the lengths of the fixed-size header value types, by type ID.
+// Types 6 (byte array) and 7 (string) are prefixed with a 2-byte length.
+var eventStreamHeaderValueLens = map[byte]int{
+	0: 0,  // true
+	1: 0,  // false
+	2: 1,  // byte
+	3: 2,  // short
+	4: 4,  // integer
+	5: 8,  // long
+	8: 8,  // timestamp
+	9: 16, // UUID
+}
+
+// parseEventStreamHeader returns the name of the first header in b and its value, if it is a string.
+func parseEventStreamHeader(b []byte) (name, value string, rest []byte, ok bool) {
+	if len(b) < 1 {
+		return "", "", nil, false
+	}
+	nameLen := int(b[0])
+	if len(b) < 1+nameLen+1 {
+		return "", "", nil, false
+	}
+	name = string(b[1 : 1+nameLen])
+	valueType := b[1+nameLen]
+	b = b[1+nameLen+1:]
+
+	switch valueType {
+	case 6, 7:
+		if len(b) < 2 {
+			return "", "", nil, false
+		}
+		valueLen := int(binary.BigEndian.Uint16(b[0:2]))
+		if len(b) < 2+valueLen {
+			return "", "", nil, false
+		}
+		if valueType == 7 {
+			value = string(b[2 : 2+valueLen])
+		}
+		return name, value, b[2+valueLen:], true
+
+	default:
+		valueLen, known := eventStreamHeaderValueLens[valueType]
+		if !known || len(b) < valueLen {
+			return "", "", nil, false
+		}
+		return name, "", b[valueLen:], true
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResponseBodyLoggersLookup(t *testing.T) {
+	testcases := map[string]struct {
+		serviceID   string
+		operation   string
+		contentType string
+		expected    ResponseBodyFormatter
+		streaming   bool
+		hash        bool
+	}{
+		"S3 GetObject": {
+			serviceID:   "S3",
+			operation:   "GetObject",
+			contentType: "application/octet-stream",
+			expected:    FormatRedactedBody,
+			streaming:   true,
+		},
+		"S3 GetObjectTorrent": {
+			serviceID:   "S3",
+			operation:   "GetObjectTorrent",
+			contentType: "application/x-bittorrent",
+			expected:    FormatRedactedBody,
+			streaming:   true,
+		},
+		"Lambda Invoke JSON payload": {
+			serviceID:   "Lambda",
+			operation:   "Invoke",
+			contentType: "application/json",
+			expected:    FormatBinaryBody,
+			hash:        true,
+		},
+		"Kinesis GetRecords": {
+			serviceID:   "Kinesis",
+			operation:   "GetRecords",
+			contentType: "application/x-amz-json-1.1",
+			expected:    FormatBinaryBody,
+			hash:        true,
+		},
+		"ECR GetDownloadUrlForLayer": {
+			serviceID:   "ECR",
+			operation:   "GetDownloadUrlForLayer",
+			contentType: "application/x-amz-json-1.1",
+			expected:    FormatBinaryBody,
+			hash:        true,
+		},
+		"octet-stream": {
+			serviceID:   "Glacier",
+			operation:   "GetJobOutput",
+			contentType: "application/octet-stream",
+			expected:    FormatBinaryBody,
+			hash:        true,
+		},
+		"CBOR": {
+			serviceID:   "CloudWatch",
+			operation:   "GetMetricData",
+			contentType: "application/x-amz-cbor-1.1",
+			expected:    FormatBinaryBody,
+			hash:        true,
+		},
+		"event stream": {
+			serviceID:   "Lambda",
+			operation:   "InvokeWithResponseStream",
+			contentType: "application/vnd.amazon.eventstream",
+			expected:    FormatEventStreamBody,
+		},
+		"JSON": {
+			serviceID:   "Lambda",
+			operation:   "GetFunction",
+			contentType: "application/json",
+			expected:    FormatJSONBody,
+		},
+		"AWS JSON with parameters": {
+			serviceID:   "DynamoDB",
+			operation:   "GetItem",
+			contentType: "application/x-amz-json-1.0; charset=utf-8",
+			expected:    FormatJSONBody,
+		},
+		"XML": {
+			serviceID:   "STS",
+			operation:   "GetCallerIdentity",
+			contentType: "text/xml",
+			expected:    FormatTextBody,
+		},
+		"no Content-Type": {
+			serviceID: "S3",
+			operation: "PutObject",
+			expected:  FormatTextBody,
+		},
+	}
+
+	registry := NewResponseBodyLoggers()
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if testcase.contentType != "" {
+				resp.Header.Set("Content-Type", testcase.contentType)
+			}
+
+			rule := registry.Lookup(testcase.serviceID, testcase.operation, resp)
+
+			expectFormatter(t, rule.Format, testcase.expected)
+			if a, e := rule.Streaming, testcase.streaming; a != e {
+				t.Errorf("expected streaming %t, got %t", e, a)
+			}
+			if a, e := rule.Hash, testcase.hash; a != e {
+				t.Errorf("expected hash %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestResponseBodyLoggersRegister(t *testing.T) {
+	registry := NewResponseBodyLoggers()
+
+	registry.Register(ResponseBodyRule{ContentType: "application/json", Format: FormatTextBody})
+	registry.Register(ResponseBodyRule{ServiceID: "Lambda", Format: FormatRedactedBody, Streaming: true})
+
+	resp := &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}}
+
+	// Later rules override equally specific built-in rules
+	expectFormatter(t, registry.Lookup("SNS", "Publish", resp).Format, FormatTextBody)
+
+	// A service rule takes precedence over Content-Type rules, but not over service and operation rules
+	expectFormatter(t, registry.Lookup("Lambda", "GetFunction", resp).Format, FormatRedactedBody)
+	expectFormatter(t, registry.Lookup("Lambda", "Invoke", resp).Format, FormatBinaryBody)
+
+	// The default registry is not modified
+	expectFormatter(t, DefaultResponseBodyLoggers.Lookup("SNS", "Publish", resp).Format, FormatJSONBody)
+}
+
+func TestFormatBinaryBody(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Content-Type": []string{"application/octet-stream"}}}
+	body := readCapture(t, "hello", 2, WithSHA256())
+
+	actual, err := FormatBinaryBody(resp, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "[Binary: 5 bytes, SHA-256: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824, Type: application/octet-stream]"
+	if actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestFormatJSONBody(t *testing.T) {
+	testcases := map[string]struct {
+		body     string
+		limit    int
+		expected string
+	}{
+		"pretty-printed": {
+			body:     `{"FunctionName":"test","Timeout":3}`,
+			limit:    1024,
+			expected: "{\n  \"FunctionName\": \"test\",\n  \"Timeout\": 3\n}\n",
+		},
+		"invalid": {
+			body:     `{"FunctionName":`,
+			limit:    1024,
+			expected: "{\"FunctionName\":\n",
+		},
+		"truncated": {
+			body:     `{"FunctionName":"test","Timeout":3}`,
+			limit:    10,
+			expected: "{\"Function\n",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			body := readCapture(t, testcase.body, testcase.limit)
+
+			actual, err := FormatJSONBody(resp, body)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != testcase.expected {
+				t.Errorf("expected %q, got %q", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatEventStreamBody(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(eventStreamMessageBytes(t, "event", ":event-type", "PayloadChunk", []byte(`{"data":"abc"}`)))
+	stream.Write(eventStreamMessageBytes(t, "event", ":event-type", "InvokeComplete", nil))
+	stream.Write(eventStreamMessageBytes(t, "exception", ":exception-type", "ThrottlingException", []byte(`{}`)))
+
+	resp := &http.Response{Header: http.Header{}}
+
+	t.Run("complete", func(t *testing.T) {
+		body := readCapture(t, stream.String(), 4096)
+
+		actual, err := FormatEventStreamBody(resp, body)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := strings.Join([]string{
+			"[Event stream: 3 messages, " + formatByteSize(int64(stream.Len())) + "]",
+			"  event PayloadChunk (14 bytes)",
+			"  event InvokeComplete (0 bytes)",
+			"  exception ThrottlingException (2 bytes)",
+		}, "\n")
+		if actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		body := readCapture(t, stream.String(), stream.Len()-1)
+
+		actual, err := FormatEventStreamBody(resp, body)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if !strings.HasPrefix(actual, "[Event stream: 2 messages, ") || !strings.Contains(actual, ", truncated]") {
+			t.Errorf("expected truncated summary of 2 messages, got %q", actual)
+		}
+	})
+}
+
+func readCapture(t *testing.T, content string, limit int, optFns ...BodyCaptureOption) *BodyCapture {
+	t.Helper()
+
+	body := NewBodyCapture(io.NopCloser(strings.NewReader(content)), limit, nil, optFns...)
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		t.Fatalf("reading body: %s", err)
+	}
+	return body
+}
+
+// eventStreamMessageBytes encodes a message with a :message-type header and one other string header. CRCs are zero.
+func eventStreamMessageBytes(t *testing.T, messageType, headerName, headerValue string, payload []byte) []byte {
+	t.Helper()
+
+	var headers bytes.Buffer
+	for _, header := range [][2]string{{":message-type", messageType}, {headerName, headerValue}, {":content-type", "application/json"}} {
+		headers.WriteByte(byte(len(header[0])))
+		headers.WriteString(header[0])
+		headers.WriteByte(7)
+		_ = binary.Write(&headers, binary.BigEndian, uint16(len(header[1])))
+		headers.WriteString(header[1])
+	}
+	// A fixed-size header, which is skipped
+	headers.WriteByte(byte(len("count")))
+	headers.WriteString("count")
+	headers.WriteByte(4)
+	_ = binary.Write(&headers, binary.BigEndian, int32(1))
+
+	totalLen := eventStreamPreludeLen + headers.Len() + len(payload) + eventStreamCRCLen
+
+	var message bytes.Buffer
+	_ = binary.Write(&message, binary.BigEndian, uint32(totalLen))
+	_ = binary.Write(&message, binary.BigEndian, uint32(headers.Len()))
+	_ = binary.Write(&message, binary.BigEndian, uint32(0))
+	message.Write(headers.Bytes())
+	message.Write(payload)
+	_ = binary.Write(&message, binary.BigEndian, uint32(0))
+
+	return message.Bytes()
+}
+
+func expectFormatter(t *testing.T, actual, expected ResponseBodyFormatter) {
+	t.Helper()
+
+	if a, e := reflect.ValueOf(actual).Pointer(), reflect.ValueOf(expected).Pointer(); a != e {
+		t.Errorf("unexpected formatter")
+	}
+}
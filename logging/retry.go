@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	RetryMaxAttemptsKey       attribute.Key = "tf_aws.retry.max_attempts"
+	RetryRemainingAttemptsKey attribute.Key = "tf_aws.retry.remaining_attempts"
+	RetryDelayKey             attribute.Key = "tf_aws.retry.delay" // milliseconds
+	RetryClassificationKey    attribute.Key = "tf_aws.retry.classification"
+	RetryErrorCodeKey         attribute.Key = "tf_aws.retry.error_code"
+)
+
+// RetryClassification is the reason a failed attempt is retried.
+type RetryClassification string
+
+const (
+	RetryClassificationThrottle           RetryClassification = "throttle"
+	RetryClassificationTransient          RetryClassification = "transient"
+	RetryClassificationClockSkew          RetryClassification = "clock_skew"
+	RetryClassificationExpiredCredentials RetryClassification = "expired_credentials"
+)
+
+// expiredCredentialsErrorCodes match the AWS SDK for Go v1, which refreshes credentials before retrying them
+var expiredCredentialsErrorCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"RequestExpired":        true,
+}
+
+// clockSkewErrorCodes are retried by the AWS SDK for Go v2 when it detects clock skew
+var clockSkewErrorCodes = map[string]bool{
+	"AccessDeniedException":     true,
+	"AuthFailure":               true,
+	"InvalidSignatureException": true,
+	"RequestInTheFuture":        true,
+	"RequestTimeTooSkewed":      true,
+	"SignatureDoesNotMatch":     true,
+}
+
+// ClassifyRetry returns the classification of a retried attempt from its error code, if any, and whether the SDK
+// considers the error a throttling error.
+func ClassifyRetry(code string, throttle bool) RetryClassification {
+	switch {
+	case expiredCredentialsErrorCodes[code]:
+		return RetryClassificationExpiredCredentials
+	case clockSkewErrorCodes[code]:
+		return RetryClassificationClockSkew
+	case throttle:
+		return RetryClassificationThrottle
+	default:
+		return RetryClassificationTransient
+	}
+}
+
+// Retry describes a retry of an AWS API operation, logged before the retried attempt.
+type Retry struct {
+	// Attempt is the number of the retried attempt, starting at 2
+	Attempt int
+
+	// MaxAttempts is zero if the number of attempts is not limited
+	MaxAttempts int
+
+	// Delay is negative if the delay before the retry is not known
+	Delay time.Duration
+
+	Classification RetryClassification
+	ErrorCode      string
+}
+
+// Fields returns the log fields for the retry.
+func (r Retry) Fields() map[string]any {
+	attributes := []attribute.KeyValue{
+		AttemptKey.Int(r.Attempt),
+		RetryClassificationKey.String(string(r.Classification)),
+	}
+	if r.Delay >= 0 {
+		attributes = append(attributes, RetryDelayKey.Int64(r.Delay.Milliseconds()))
+	}
+	if r.MaxAttempts > 0 {
+		attributes = append(attributes,
+			RetryMaxAttemptsKey.Int(r.MaxAttempts),
+			RetryRemainingAttemptsKey.Int(max(r.MaxAttempts-r.Attempt, 0)),
+		)
+	}
+	if r.ErrorCode != "" {
+		attributes = append(attributes, RetryErrorCodeKey.String(r.ErrorCode))
+	}
+
+	fields := make(map[string]any, len(attributes))
+	for _, attribute := range attributes {
+		fields[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	return fields
+}
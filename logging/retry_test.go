@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassifyRetry(t *testing.T) {
+	testcases := map[string]struct {
+		code     string
+		throttle bool
+		expected RetryClassification
+	}{
+		"throttle": {
+			code:     "ThrottlingException",
+			throttle: true,
+			expected: RetryClassificationThrottle,
+		},
+		"transient error code": {
+			code:     "InternalFailure",
+			expected: RetryClassificationTransient,
+		},
+		"no error code": {
+			expected: RetryClassificationTransient,
+		},
+		"clock skew": {
+			code:     "RequestTimeTooSkewed",
+			expected: RetryClassificationClockSkew,
+		},
+		"signature clock skew": {
+			code:     "SignatureDoesNotMatch",
+			expected: RetryClassificationClockSkew,
+		},
+		"expired token": {
+			code:     "ExpiredTokenException",
+			expected: RetryClassificationExpiredCredentials,
+		},
+		"request expired": {
+			code:     "RequestExpired",
+			expected: RetryClassificationExpiredCredentials,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if a, e := ClassifyRetry(testcase.code, testcase.throttle), testcase.expected; a != e {
+				t.Errorf("expected %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestRetryFields(t *testing.T) {
+	testcases := map[string]struct {
+		retry    Retry
+		expected map[string]any
+	}{
+		"limited": {
+			retry: Retry{
+				Attempt:        2,
+				MaxAttempts:    3,
+				Delay:          1500 * time.Millisecond,
+				Classification: RetryClassificationThrottle,
+				ErrorCode:      "Throttling",
+			},
+			expected: map[string]any{
+				"tf_aws.retry.attempt":            int64(2),
+				"tf_aws.retry.max_attempts":       int64(3),
+				"tf_aws.retry.remaining_attempts": int64(1),
+				"tf_aws.retry.delay":              int64(1500),
+				"tf_aws.retry.classification":     "throttle",
+				"tf_aws.retry.error_code":         "Throttling",
+			},
+		},
+		"unlimited": {
+			retry: Retry{
+				Attempt:        25,
+				Delay:          time.Second,
+				Classification: RetryClassificationTransient,
+			},
+			expected: map[string]any{
+				"tf_aws.retry.attempt":        int64(25),
+				"tf_aws.retry.delay":          int64(1000),
+				"tf_aws.retry.classification": "transient",
+			},
+		},
+		"unknown delay": {
+			retry: Retry{
+				Attempt:        2,
+				Delay:          -1,
+				Classification: RetryClassificationTransient,
+			},
+			expected: map[string]any{
+				"tf_aws.retry.attempt":        int64(2),
+				"tf_aws.retry.classification": "transient",
+			},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(testcase.expected, testcase.retry.Fields()); diff != "" {
+				t.Errorf("unexpected fields (+got, -expected): %s", diff)
+			}
+		})
+	}
+}
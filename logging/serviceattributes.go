@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// fieldAttribute maps an operation input field to the attribute it is recorded as.
+type fieldAttribute struct {
+	field string
+	key   attribute.Key
+}
+
+// serviceFields lists, by service ID, the input fields identifying the resources an operation acts on.
+// The AWS SDK for Go v1 and v2 use the same service IDs and input field names.
+var serviceFields = map[string][]fieldAttribute{
+	"CloudFormation": {
+		{field: "StackName", key: "aws.cloudformation.stack_name"},
+	},
+	"EC2": {
+		{field: "InstanceId", key: "aws.ec2.instance_id"},
+		{field: "InstanceIds", key: "aws.ec2.instance_ids"},
+		{field: "VpcId", key: "aws.ec2.vpc_id"},
+		{field: "VpcIds", key: "aws.ec2.vpc_ids"},
+	},
+	"EventBridge": {
+		{field: "EventBusName", key: "aws.eventbridge.event_bus_name"},
+	},
+	"IAM": {
+		{field: "RoleName", key: "aws.iam.role_name"},
+		{field: "UserName", key: "aws.iam.user_name"},
+	},
+	"KMS": {
+		{field: "KeyId", key: "aws.kms.key_id"},
+	},
+	"Lambda": {
+		{field: "FunctionName", key: "aws.lambda.function_name"},
+		{field: "Qualifier", key: "aws.lambda.qualifier"},
+	},
+	"S3": {
+		{field: "Bucket", key: semconv.AWSS3BucketKey},
+		{field: "Key", key: semconv.AWSS3KeyKey},
+		{field: "VersionId", key: "aws.s3.version_id"},
+		{field: "UploadId", key: semconv.AWSS3UploadIDKey},
+		{field: "PartNumber", key: semconv.AWSS3PartNumberKey},
+		{field: "CopySource", key: semconv.AWSS3CopySourceKey},
+	},
+	"Secrets Manager": {
+		{field: "SecretId", key: "aws.secretsmanager.secret_id"},
+	},
+	"SNS": {
+		{field: "TopicArn", key: "aws.sns.topic_arn"},
+	},
+}
+
+// ServiceAttributes returns attributes for the resource identifiers in the input parameters of an AWS API operation,
+// e.g. the function name of a Lambda operation. Fields that are not set are skipped.
+// params may be an input from either the AWS SDK for Go v1 or v2.
+func ServiceAttributes(serviceID string, params any) []attribute.KeyValue {
+	fields, ok := serviceFields[serviceID]
+	if !ok {
+		return nil
+	}
+
+	return fieldAttributes(params, fields)
+}
+
+func fieldAttributes(params any, fields []fieldAttribute) []attribute.KeyValue {
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var attributes []attribute.KeyValue
+	for _, f := range fields {
+		if attr, ok := fieldAttributeValue(v.FieldByName(f.field), f.key); ok {
+			attributes = append(attributes, attr)
+		}
+	}
+
+	return attributes
+}
+
+// fieldAttributeValue converts string, integer and string slice fields, and pointers to them, to an attribute.
+func fieldAttributeValue(v reflect.Value, key attribute.Key) (attribute.KeyValue, bool) {
+	if !v.IsValid() {
+		return attribute.KeyValue{}, false
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return attribute.KeyValue{}, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); s != "" {
+			return key.String(s), true
+		}
+
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return key.Int64(v.Int()), true
+
+	case reflect.Slice:
+		var s []string
+		for i := range v.Len() {
+			e := v.Index(i)
+			if e.Kind() == reflect.Pointer {
+				if e.IsNil() {
+					continue
+				}
+				e = e.Elem()
+			}
+			if e.Kind() == reflect.String {
+				s = append(s, e.String())
+			}
+		}
+		if len(s) > 0 {
+			return key.StringSlice(s), true
+		}
+	}
+
+	return attribute.KeyValue{}, false
+}
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// LevelTrace is the slog level used for Logger.Trace.
+const LevelTrace = slog.LevelDebug - 4
+
+// slogModuleKey is the attribute holding the name of a sub-logger, matching the key used by hclog for JSON output
+const slogModuleKey = "@module"
+
+type slogLoggerKeyT string
+
+const slogLoggerKey slogLoggerKeyT = "slog-logger-key"
+
+// slogContext is the slog.Logger and sub-logger name stored in the context
+type slogContext struct {
+	logger *slog.Logger
+	name   string
+}
+
+// SlogLogger is a Logger that writes to a log/slog Logger stored in the context.
+// Log fields, such as the "tf_aws.*" and OpenTelemetry attributes, are written as slog attributes.
+type SlogLogger struct{}
+
+var _ Logger = SlogLogger{}
+
+// NewSlogLogger stores logger in the context. If logger is nil, slog.Default() is used.
+func NewSlogLogger(ctx context.Context, logger *slog.Logger) (context.Context, SlogLogger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx = context.WithValue(ctx, slogLoggerKey, slogContext{logger: logger})
+
+	return ctx, SlogLogger{}
+}
+
+func slogFromContext(ctx context.Context) slogContext {
+	if v, ok := ctx.Value(slogLoggerKey).(slogContext); ok {
+		return v
+	}
+	return slogContext{logger: slog.Default()}
+}
+
+func (l SlogLogger) SubLogger(ctx context.Context, name string) (context.Context, Logger) {
+	v := slogFromContext(ctx)
+	if v.name != "" {
+		name = v.name + "." + name
+	}
+	v.name = name
+	v.logger = v.logger.With(slogModuleKey, name)
+
+	return context.WithValue(ctx, slogLoggerKey, v), l
+}
+
+func (l SlogLogger) Warn(ctx context.Context, msg string, fields ...map[string]any) {
+	l.log(ctx, slog.LevelWarn, msg, fields)
+}
+
+func (l SlogLogger) Info(ctx context.Context, msg string, fields ...map[string]any) {
+	l.log(ctx, slog.LevelInfo, msg, fields)
+}
+
+func (l SlogLogger) Debug(ctx context.Context, msg string, fields ...map[string]any) {
+	l.log(ctx, slog.LevelDebug, msg, fields)
+}
+
+func (l SlogLogger) Trace(ctx context.Context, msg string, fields ...map[string]any) {
+	l.log(ctx, LevelTrace, msg, fields)
+}
+
+func (l SlogLogger) log(ctx context.Context, level slog.Level, msg string, fields []map[string]any) {
+	logger := slogFromContext(ctx).logger
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, msg, slogAttrs(fields...)...)
+}
+
+func (l SlogLogger) SetField(ctx context.Context, key string, value any) context.Context {
+	v := slogFromContext(ctx)
+	v.logger = v.logger.With(slog.Any(key, value))
+
+	return context.WithValue(ctx, slogLoggerKey, v)
+}
+
+// slogAttrs converts log fields to attributes, sorted by key. Later fields override earlier ones.
+func slogAttrs(fields ...map[string]any) []slog.Attr {
+	merged := make(map[string]any)
+	for _, m := range fields {
+		maps.Copy(merged, m)
+	}
+
+	attrs := make([]slog.Attr, 0, len(merged))
+	for _, k := range slices.Sorted(maps.Keys(merged)) {
+		attrs = append(attrs, slog.Any(k, merged[k]))
+	}
+	return attrs
+}
+
+var missingContextLogger atomic.Pointer[slog.Logger]
+
+// SetMissingContextLogger sets the logger used by the AWS SDK for Go v1 and v2 debug loggers for messages logged
+// without a context. If logger is nil, messages are written using the standard library log package.
+func SetMissingContextLogger(logger *slog.Logger) {
+	missingContextLogger.Store(logger)
+}
+
+// MissingContextLogger returns the logger for messages logged without a context.
+func MissingContextLogger() *slog.Logger {
+	if logger := missingContextLogger.Load(); logger != nil {
+		return logger
+	}
+	return defaultMissingContextLogger
+}
+
+// defaultMissingContextLogger writes lines of the form "[DEBUG] missing_context: <message> <key>=<value>", using the
+// standard library log package, so that the level can be parsed by Terraform
+var defaultMissingContextLogger = slog.New(missingContextHandler{})
+
+type missingContextHandler struct {
+	attrs  []slog.Attr
+	groups []string
+}
+
+var _ slog.Handler = missingContextHandler{}
+
+func (h missingContextHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h missingContextHandler) Handle(_ context.Context, r slog.Record) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[%s] missing_context: %s", slogLevelName(r.Level), r.Message)
+
+	for _, attr := range h.attrs {
+		fmt.Fprintf(&builder, " %s=%v", attr.Key, attr.Value)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&builder, " %s=%v", h.key(attr.Key), attr.Value)
+		return true
+	})
+
+	return log.Output(4, builder.String()) //nolint:mnd // slog.Logger.log, slog.Logger.Log and the caller
+}
+
+func (h missingContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.attrs = slices.Clone(h.attrs)
+	for _, attr := range attrs {
+		h.attrs = append(h.attrs, slog.Attr{Key: h.key(attr.Key), Value: attr.Value})
+	}
+	return h
+}
+
+func (h missingContextHandler) WithGroup(name string) slog.Handler {
+	h.groups = append(slices.Clone(h.groups), name)
+	return h
+}
+
+func (h missingContextHandler) key(k string) string {
+	return strings.Join(append(slices.Clone(h.groups), k), ".")
+}
+
+func slogLevelName(level slog.Level) string {
+	if level < slog.LevelDebug {
+		return "TRACE"
+	}
+	return level.String()
+}
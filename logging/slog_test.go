@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: LevelTrace,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	ctx, logger := NewSlogLogger(context.Background(), slog.New(handler))
+
+	ctx = logger.SetField(ctx, string(AwsSdkKey), "aws-sdk-go-v2")
+	ctx = logger.SetField(ctx, "rpc.service", "STS")
+	ctx, subLogger := logger.SubLogger(ctx, "aws-base")
+	ctx, subLogger = subLogger.SubLogger(ctx, "sts")
+
+	subLogger.Debug(ctx, "HTTP Request Sent", map[string]any{
+		"http.method":          "POST",
+		"http.response_length": int64(1024),
+	}, map[string]any{
+		"tf_aws.retry.attempt": 2,
+		"aws.ec2.instance_ids": []string{"i-1", "i-2"},
+	})
+	subLogger.Trace(ctx, "trace")
+	subLogger.Warn(ctx, "warn")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if a, e := len(lines), 3; a != e {
+		t.Fatalf("expected %d lines, got %d:\n%s", e, a, buf.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("parsing log line: %s", err)
+	}
+	expected := map[string]any{
+		"level":                "DEBUG",
+		"msg":                  "HTTP Request Sent",
+		"tf_aws.sdk":           "aws-sdk-go-v2",
+		"rpc.service":          "STS",
+		"@module":              "aws-base.sts",
+		"http.method":          "POST",
+		"http.response_length": float64(1024),
+		"tf_aws.retry.attempt": float64(2),
+		"aws.ec2.instance_ids": []any{"i-1", "i-2"},
+	}
+	if diff := cmp.Diff(expected, entry); diff != "" {
+		t.Errorf("unexpected log entry (+got, -expected): %s", diff)
+	}
+
+	for i, level := range []string{"DEBUG-4", "WARN"} {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(lines[i+1]), &entry); err != nil {
+			t.Fatalf("parsing log line: %s", err)
+		}
+		if a, e := entry["level"], level; a != e {
+			t.Errorf("expected level %q, got %q", e, a)
+		}
+	}
+}
+
+func TestSlogLogger_level(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, logger := NewSlogLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logger.Debug(ctx, "debug")
+	logger.Trace(ctx, "trace")
+	logger.Info(ctx, "info")
+
+	if a, e := strings.Count(buf.String(), "\n"), 1; a != e {
+		t.Errorf("expected %d line, got %d:\n%s", e, a, buf.String())
+	}
+}
+
+func TestMissingContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	flags, writer := log.Flags(), log.Writer()
+	log.SetFlags(0)
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		log.SetFlags(flags)
+		log.SetOutput(writer)
+	})
+
+	MissingContextLogger().Debug("message", string(AwsSdkKey), "aws-sdk-go-v2")
+	MissingContextLogger().With("group", "a").WithGroup("g").Log(context.Background(), slog.LevelWarn, "warning", "k", "v")
+	MissingContextLogger().Log(context.Background(), LevelTrace, "trace")
+
+	expected := `[DEBUG] missing_context: message tf_aws.sdk=aws-sdk-go-v2
+[WARN] missing_context: warning group=a g.k=v
+[TRACE] missing_context: trace
+`
+	if a := buf.String(); a != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, a)
+	}
+
+	var slogBuf bytes.Buffer
+	SetMissingContextLogger(slog.New(slog.NewTextHandler(&slogBuf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() {
+		SetMissingContextLogger(nil)
+	})
+
+	MissingContextLogger().Debug("routed", string(AwsSdkKey), "aws-sdk-go")
+
+	if a, e := slogBuf.String(), "msg=routed tf_aws.sdk=aws-sdk-go"; !strings.Contains(a, e) {
+		t.Errorf("expected %q to contain %q", a, e)
+	}
+	if a := buf.String(); a != expected {
+		t.Errorf("expected no further log package output, got\n%s", a)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope name used for spans created by both AWS SDK for Go v1 and v2 clients.
+const TracerName = "github.com/hashicorp/aws-sdk-go-base/v2"
+
+const (
+	AttemptKey attribute.Key = "tf_aws.retry.attempt"
+)
+
+// traceContextPropagator propagates W3C trace context. AWS services ignore headers they do not support.
+// Callers must start attempt spans after the request is signed, so that the headers are not part of the signature,
+// and remove the headers with RemoveTraceContext before a request is signed again for a retry.
+var traceContextPropagator = propagation.TraceContext{}
+
+// Tracer returns the Tracer used for AWS API calls.
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	return tp.Tracer(TracerName)
+}
+
+// OperationSpanName returns the span name for an AWS API operation, e.g. "S3.GetObject".
+func OperationSpanName(serviceID, operation string) string {
+	return serviceID + "." + operation
+}
+
+// StartOperationSpan starts a span covering an AWS API operation, including all retry attempts.
+// It is an internal span, because each attempt span is the client span of a remote call.
+func StartOperationSpan(ctx context.Context, tracer trace.Tracer, serviceID, operation string, attributes []attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, OperationSpanName(serviceID, operation),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+// StartAttemptSpan starts a span covering a single HTTP attempt of an AWS API operation,
+// and injects the W3C trace context of the new span into the request headers.
+func StartAttemptSpan(ctx context.Context, tracer trace.Tracer, serviceID, operation string, attempt int, req *http.Request) (context.Context, trace.Span) {
+	attributes := append([]attribute.KeyValue{AttemptKey.Int(attempt)}, httpconv.ClientRequest(req)...)
+
+	ctx, span := tracer.Start(ctx, OperationSpanName(serviceID, operation)+" attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+
+	traceContextPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return ctx, span
+}
+
+// RemoveTraceContext removes the trace context headers injected by StartAttemptSpan.
+func RemoveTraceContext(header http.Header) {
+	for _, field := range traceContextPropagator.Fields() {
+		header.Del(field)
+	}
+}
+
+// EndAttemptSpan records the HTTP response, if any, and the error, if any, on a span and ends it.
+func EndAttemptSpan(span trace.Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttributes(httpconv.ClientResponse(resp)...)
+		if code, description := httpconv.ClientStatus(resp.StatusCode); code == codes.Error {
+			span.SetStatus(code, description)
+		}
+	}
+	EndSpan(span, err)
+}
+
+// EndSpan records the error, if any, on a span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// Verbosity is the level of detail of HTTP request and response logging.
+type Verbosity string
+
+const (
+	// VerbosityFull logs requests and responses, including bodies. This is the default.
+	VerbosityFull Verbosity = "full"
+
+	// VerbosityHeaders logs requests and responses without bodies.
+	VerbosityHeaders Verbosity = "headers"
+
+	// VerbosityErrors logs requests and responses, including bodies, only for failed requests.
+	// A request is logged with its response, once the outcome is known.
+	VerbosityErrors Verbosity = "errors"
+
+	// VerbosityOff disables request and response logging.
+	VerbosityOff Verbosity = "off"
+)
+
+// validate returns an error if v is not one of the Verbosity constants.
+func (v Verbosity) validate() error {
+	switch v {
+	case VerbosityFull, VerbosityHeaders, VerbosityErrors, VerbosityOff:
+		return nil
+	}
+	return fmt.Errorf("invalid log verbosity %q, expected one of %q, %q, %q or %q", v, VerbosityFull, VerbosityHeaders, VerbosityErrors, VerbosityOff)
+}
+
+// LogPolicy decides which HTTP requests and responses are logged, and in how much detail.
+// It is shared by the AWS SDK for Go v1 and v2 loggers. A nil LogPolicy logs everything at VerbosityFull.
+type LogPolicy struct {
+	verbosity Verbosity
+	services  map[string]Verbosity
+
+	// sampleRate logs 1 in sampleRate successful responses
+	sampleRate uint64
+	successes  atomic.Uint64
+}
+
+// NewLogPolicy returns a LogPolicy. An empty verbosity is VerbosityFull. services overrides the verbosity by
+// service ID, where an empty verbosity is ignored. If sampleRate is greater than 1, only 1 in sampleRate successful
+// responses is logged, together with its request, while all failed requests are logged.
+// An error is returned for any other verbosity than the Verbosity constants.
+func NewLogPolicy(verbosity Verbosity, services map[string]Verbosity, sampleRate int) (*LogPolicy, error) {
+	if verbosity == "" {
+		verbosity = VerbosityFull
+	}
+	if err := verbosity.validate(); err != nil {
+		return nil, err
+	}
+	for _, serviceID := range slices.Sorted(maps.Keys(services)) {
+		if v := services[serviceID]; v != "" {
+			if err := v.validate(); err != nil {
+				return nil, fmt.Errorf("service %q: %w", serviceID, err)
+			}
+		}
+	}
+
+	p := &LogPolicy{
+		verbosity: verbosity,
+		services:  services,
+	}
+	if sampleRate > 1 {
+		p.sampleRate = uint64(sampleRate)
+	}
+
+	return p, nil
+}
+
+// Verbosity returns the verbosity for a service.
+func (p *LogPolicy) Verbosity(serviceID string) Verbosity {
+	if p == nil {
+		return VerbosityFull
+	}
+	if v, ok := p.services[serviceID]; ok && v != "" {
+		return v
+	}
+	return p.verbosity
+}
+
+// Disabled reports whether nothing is logged for any service.
+func (p *LogPolicy) Disabled() bool {
+	if p == nil {
+		return false
+	}
+	if p.verbosity != VerbosityOff {
+		return false
+	}
+	for _, v := range p.services {
+		if v != "" && v != VerbosityOff {
+			return false
+		}
+	}
+	return true
+}
+
+// DefersRequest reports whether requests to a service are logged only once the outcome of the request is known.
+func (p *LogPolicy) DefersRequest(serviceID string) bool {
+	if p == nil {
+		return false
+	}
+	return p.Verbosity(serviceID) == VerbosityErrors || p.sampleRate > 0
+}
+
+// LogsResponse reports whether a request and its response are logged. failed is set when the request returned an
+// error or an HTTP status code of 400 or above. Each call for a successful request counts towards the sampling rate.
+func (p *LogPolicy) LogsResponse(serviceID string, failed bool) bool {
+	switch p.Verbosity(serviceID) {
+	case VerbosityOff:
+		return false
+	case VerbosityErrors:
+		return failed
+	}
+
+	if failed || p == nil || p.sampleRate == 0 {
+		return true
+	}
+	return (p.successes.Add(1)-1)%p.sampleRate == 0
+}
+
+// LogsBodies reports whether request and response bodies are logged for a service.
+func (p *LogPolicy) LogsBodies(serviceID string) bool {
+	v := p.Verbosity(serviceID)
+	return v == VerbosityFull || v == VerbosityErrors
+}
@@ -0,0 +1,207 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"testing"
+)
+
+func TestLogPolicy_Verbosity(t *testing.T) {
+	testCases := map[string]struct {
+		policy    *LogPolicy
+		serviceID string
+		expected  Verbosity
+	}{
+		"nil": {
+			policy:   nil,
+			expected: VerbosityFull,
+		},
+		"empty": {
+			policy:   mustNewLogPolicy("", nil, 0),
+			expected: VerbosityFull,
+		},
+		"set": {
+			policy:   mustNewLogPolicy(VerbosityHeaders, nil, 0),
+			expected: VerbosityHeaders,
+		},
+		"override": {
+			policy:    mustNewLogPolicy(VerbosityOff, map[string]Verbosity{"S3": VerbosityErrors}, 0),
+			serviceID: "S3",
+			expected:  VerbosityErrors,
+		},
+		"other service": {
+			policy:    mustNewLogPolicy(VerbosityOff, map[string]Verbosity{"S3": VerbosityErrors}, 0),
+			serviceID: "STS",
+			expected:  VerbosityOff,
+		},
+		"empty override": {
+			policy:    mustNewLogPolicy(VerbosityHeaders, map[string]Verbosity{"S3": ""}, 0),
+			serviceID: "S3",
+			expected:  VerbosityHeaders,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if a, e := testcase.policy.Verbosity(testcase.serviceID), testcase.expected; a != e {
+				t.Errorf("expected %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestLogPolicy_Disabled(t *testing.T) {
+	testCases := map[string]struct {
+		policy   *LogPolicy
+		expected bool
+	}{
+		"nil": {
+			policy: nil,
+		},
+		"full": {
+			policy: mustNewLogPolicy(VerbosityFull, nil, 0),
+		},
+		"off": {
+			policy:   mustNewLogPolicy(VerbosityOff, nil, 0),
+			expected: true,
+		},
+		"off with override": {
+			policy: mustNewLogPolicy(VerbosityOff, map[string]Verbosity{"S3": VerbosityErrors}, 0),
+		},
+		"off with off override": {
+			policy:   mustNewLogPolicy(VerbosityOff, map[string]Verbosity{"S3": VerbosityOff}, 0),
+			expected: true,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if a, e := testcase.policy.Disabled(), testcase.expected; a != e {
+				t.Errorf("expected %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestLogPolicy_LogsResponse(t *testing.T) {
+	testCases := map[string]struct {
+		policy *LogPolicy
+
+		// failed is the outcome of consecutive requests
+		failed   []bool
+		expected []bool
+	}{
+		"nil": {
+			policy:   nil,
+			failed:   []bool{false, true},
+			expected: []bool{true, true},
+		},
+		"headers": {
+			policy:   mustNewLogPolicy(VerbosityHeaders, nil, 0),
+			failed:   []bool{false, true},
+			expected: []bool{true, true},
+		},
+		"errors": {
+			policy:   mustNewLogPolicy(VerbosityErrors, nil, 0),
+			failed:   []bool{false, true},
+			expected: []bool{false, true},
+		},
+		"off": {
+			policy:   mustNewLogPolicy(VerbosityOff, nil, 0),
+			failed:   []bool{false, true},
+			expected: []bool{false, false},
+		},
+		"sampled": {
+			policy:   mustNewLogPolicy(VerbosityFull, nil, 2),
+			failed:   []bool{false, false, true, false, false},
+			expected: []bool{true, false, true, true, false},
+		},
+		"sample rate 1": {
+			policy:   mustNewLogPolicy(VerbosityFull, nil, 1),
+			failed:   []bool{false, false},
+			expected: []bool{true, true},
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for i, failed := range testcase.failed {
+				if a, e := testcase.policy.LogsResponse("STS", failed), testcase.expected[i]; a != e {
+					t.Errorf("request %d: expected %t, got %t", i, e, a)
+				}
+			}
+		})
+	}
+}
+
+func TestLogPolicy_DefersRequest(t *testing.T) {
+	testCases := map[string]struct {
+		policy   *LogPolicy
+		expected bool
+	}{
+		"nil": {
+			policy: nil,
+		},
+		"full": {
+			policy: mustNewLogPolicy(VerbosityFull, nil, 0),
+		},
+		"errors": {
+			policy:   mustNewLogPolicy(VerbosityErrors, nil, 0),
+			expected: true,
+		},
+		"sampled": {
+			policy:   mustNewLogPolicy(VerbosityHeaders, nil, 10),
+			expected: true,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if a, e := testcase.policy.DefersRequest("STS"), testcase.expected; a != e {
+				t.Errorf("expected %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestNewLogPolicy_invalid(t *testing.T) {
+	testCases := map[string]struct {
+		verbosity     Verbosity
+		services      map[string]Verbosity
+		expectedError string
+	}{
+		"verbosity": {
+			verbosity:     "debug",
+			expectedError: `invalid log verbosity "debug", expected one of "full", "headers", "errors" or "off"`,
+		},
+		"case": {
+			verbosity:     "Full",
+			expectedError: `invalid log verbosity "Full", expected one of "full", "headers", "errors" or "off"`,
+		},
+		"service": {
+			services:      map[string]Verbosity{"S3": VerbosityOff, "STS": "none"},
+			expectedError: `service "STS": invalid log verbosity "none", expected one of "full", "headers", "errors" or "off"`,
+		},
+	}
+
+	for name, testcase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewLogPolicy(testcase.verbosity, testcase.services, 0)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if a, e := err.Error(), testcase.expectedError; a != e {
+				t.Errorf("expected error %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func mustNewLogPolicy(verbosity Verbosity, services map[string]Verbosity, sampleRate int) *LogPolicy {
+	p, err := NewLogPolicy(verbosity, services, sampleRate)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
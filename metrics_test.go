@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const throttlingResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>Throttling</Code>
+    <Message>Rate exceeded</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`
+
+func TestMetrics(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(throttlingResponse))
+			return
+		}
+		// Flushing before writing the body sends a chunked response, without a Content-Length
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	withLogger, err := withRequestResponseLogger(&Config{
+		MeterProvider:    mp,
+		SuppressDebugLog: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			o.RateLimiter = noRateLimiter{}
+		}),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	if _, err := client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collecting metrics: %s", err)
+	}
+
+	operation := []attribute.KeyValue{
+		attribute.String("rpc.service", "STS"),
+		attribute.String("rpc.method", "GetCallerIdentity"),
+		attribute.String("aws.region", "us-east-1"),
+	}
+	ok := attribute.NewSet(append(operation, attribute.Int("http.status_code", http.StatusOK))...)
+	throttled := attribute.NewSet(append(operation, attribute.Int("http.status_code", http.StatusBadRequest))...)
+
+	expectSum(t, rm, "aws.api.calls", throttled, 1)
+	expectSum(t, rm, "aws.api.retries", ok, 1)
+	expectSum(t, rm, "aws.api.throttles", throttled, 1)
+	expectNoSum(t, rm, "aws.api.throttles", ok)
+	expectSum(t, rm, "aws.api.response.size", ok, int64(len(getCallerIdentityResponse)))
+
+	if a, e := histogramCount(t, rm, "aws.api.attempt.duration"), uint64(2); a != e {
+		t.Errorf("expected %d duration measurements, got %d", e, a)
+	}
+}
+
+type noRateLimiter struct{}
+
+func (noRateLimiter) GetToken(context.Context, uint) (func() error, error) {
+	return func() error { return nil }, nil
+}
+
+func (noRateLimiter) AddTokens(uint) error {
+	return nil
+}
+
+func findMetric(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	t.Helper()
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	t.Fatalf("metric %q not recorded", name)
+	return metricdata.Metrics{}
+}
+
+func sumValue(t *testing.T, rm metricdata.ResourceMetrics, name string, attributes attribute.Set) (int64, bool) {
+	t.Helper()
+
+	sum, ok := findMetric(t, rm, name).Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("metric %q is not an int64 sum", name)
+	}
+	for _, dp := range sum.DataPoints {
+		if dp.Attributes.Equals(&attributes) {
+			return dp.Value, true
+		}
+	}
+	return 0, false
+}
+
+func expectSum(t *testing.T, rm metricdata.ResourceMetrics, name string, attributes attribute.Set, expected int64) {
+	t.Helper()
+
+	actual, ok := sumValue(t, rm, name, attributes)
+	if !ok {
+		t.Errorf("metric %q: no data point for %s", name, attributes.Encoded(attribute.DefaultEncoder()))
+		return
+	}
+	if actual != expected {
+		t.Errorf("metric %q: expected %d for %s, got %d", name, expected, attributes.Encoded(attribute.DefaultEncoder()), actual)
+	}
+}
+
+func expectNoSum(t *testing.T, rm metricdata.ResourceMetrics, name string, attributes attribute.Set) {
+	t.Helper()
+
+	if _, ok := sumValue(t, rm, name, attributes); ok {
+		t.Errorf("metric %q: expected no data point for %s", name, attributes.Encoded(attribute.DefaultEncoder()))
+	}
+}
+
+func histogramCount(t *testing.T, rm metricdata.ResourceMetrics, name string) uint64 {
+	t.Helper()
+
+	histogram, ok := findMetric(t, rm, name).Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("metric %q is not a float64 histogram", name)
+	}
+	var count uint64
+	for _, dp := range histogram.DataPoints {
+		count += dp.Count
+	}
+	return count
+}
@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+const (
+	// retryMiddlewareID is the ID of the AWS SDK for Go v2 retry middleware
+	retryMiddlewareID = "Retry"
+
+	// retryMetricsHeaderMiddlewareID is the ID of the middleware that sets the "amz-sdk-request" header in older
+	// versions of the AWS SDK for Go v2. Newer versions set the header in the retry middleware.
+	retryMetricsHeaderMiddlewareID = "RetryMetricsHeader"
+)
+
+// addRetryLogger adds middleware around the retry middleware that logs each retry of an operation and adds the attempt
+// number to the log fields of each attempt. Retries are not logged for services with logging turned off.
+// Retries abandoned by a netretry.Retryer are logged as warnings.
+// The delay before each retry is only logged if the client's retryer is wrapped by newRetryDelayRecorder.
+func addRetryLogger(stack *middleware.Stack, policy *logging.LogPolicy) error {
+	if _, ok := stack.Finalize.Get(retryMiddlewareID); !ok {
+		return nil
+	}
+	if err := stack.Finalize.Insert(&retryLogger{}, retryMiddlewareID, middleware.Before); err != nil {
+		return err
+	}
+
+	// The attempt logger reads the attempt number from the "amz-sdk-request" header, so it must run after the header is set
+	relativeTo := retryMiddlewareID
+	if _, ok := stack.Finalize.Get(retryMetricsHeaderMiddlewareID); ok {
+		relativeTo = retryMetricsHeaderMiddlewareID
+	}
+	return stack.Finalize.Insert(&attemptLogger{policy: policy}, relativeTo, middleware.After)
+}
+
+type retryStateKeyT string
+
+const retryStateKey retryStateKeyT = "retry-state"
+
+// retryState holds the outcome of the previous attempt of an operation.
+type retryState struct {
+	classification logging.RetryClassification
+	errorCode      string
+
+	// delay is the delay chosen by the retryer, or negative if it is not known
+	delay time.Duration
+}
+
+// retryLogger runs once per operation, before the retry middleware.
+type retryLogger struct{}
+
+func (l *retryLogger) ID() string {
+	return "TF_AWS_RetryLogger"
+}
+
+func (l *retryLogger) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	ctx = context.WithValue(ctx, retryStateKey, &retryState{delay: -1})
+
+	out, metadata, err = next.HandleFinalize(ctx, in)
+
+	err = unwrapRetryAttemptError(err)
+
+	if abandoned, ok := errs.As[*netretry.AbandonedError](err); ok {
+		logger := logging.RetrieveLogger(ctx)
+		logger.Warn(ctx, "Disabling retries due to networking error", map[string]any{
+			"error":                      abandoned.Err,
+			"tf_aws.network_error_class": abandoned.Class,
+		})
+	}
+
+	return out, metadata, err
+}
+
+// attemptLogger runs for each attempt, after the retry middleware.
+type attemptLogger struct {
+	policy *logging.LogPolicy
+}
+
+func (l *attemptLogger) ID() string {
+	return "TF_AWS_AttemptLogger"
+}
+
+func (l *attemptLogger) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	request, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown request type %T", in.Request)
+	}
+
+	logger := logging.RetrieveLogger(ctx)
+
+	attempt, maxAttempts := sdkRequestAttempts(request.Request)
+	ctx = logger.SetField(ctx, string(logging.AttemptKey), attempt)
+
+	state, _ := ctx.Value(retryStateKey).(*retryState)
+
+	if state != nil && attempt > 1 && l.policy.Verbosity(awsmiddleware.GetServiceID(ctx)) != logging.VerbosityOff {
+		logger.Debug(ctx, "Retrying AWS API request", logging.Retry{
+			Attempt:        attempt,
+			MaxAttempts:    maxAttempts,
+			Delay:          state.delay,
+			Classification: state.classification,
+			ErrorCode:      state.errorCode,
+		}.Fields())
+	}
+
+	out, metadata, err = next.HandleFinalize(ctx, in)
+
+	if state != nil && err != nil {
+		state.classification, state.errorCode = classifyRetryError(err)
+		state.delay = -1
+
+		// Pass the retry state to the retryer, which records the delay it chooses
+		err = &retryAttemptError{err: err, state: state}
+	}
+
+	return out, metadata, err
+}
+
+// retryAttemptError carries the retry state of an operation from a failed attempt to the retryer.
+// It is transparent to errors.As and errors.Is, and is removed by retryLogger where possible.
+type retryAttemptError struct {
+	err   error
+	state *retryState
+}
+
+func (e *retryAttemptError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAttemptError) Unwrap() error {
+	return e.err
+}
+
+// unwrapRetryAttemptError removes a retryAttemptError returned by the retry middleware, either as is or when the
+// maximum number of attempts is reached or retries of a networking error are abandoned.
+func unwrapRetryAttemptError(err error) error {
+	switch e := err.(type) {
+	case *retryAttemptError:
+		return e.err
+	case *retry.MaxAttemptsError:
+		switch inner := e.Err.(type) {
+		case *retryAttemptError:
+			return &retry.MaxAttemptsError{
+				Attempt: e.Attempt,
+				Err:     inner.err,
+			}
+		case *netretry.AbandonedError:
+			if attemptErr, ok := inner.Err.(*retryAttemptError); ok {
+				return &retry.MaxAttemptsError{
+					Attempt: e.Attempt,
+					Err: &netretry.AbandonedError{
+						Class: inner.Class,
+						Err:   attemptErr.err,
+					},
+				}
+			}
+		}
+	}
+	return err
+}
+
+// retryDelayRecorder records the delay chosen by the wrapped retryer, so that it can be logged with the next attempt.
+type retryDelayRecorder struct {
+	aws.RetryerV2
+}
+
+// newRetryDelayRecorder wraps retryer so that the retry logger logs the delay before each retry.
+func newRetryDelayRecorder(retryer aws.RetryerV2) aws.RetryerV2 {
+	return &retryDelayRecorder{
+		RetryerV2: retryer,
+	}
+}
+
+func (r *retryDelayRecorder) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay, delayErr := r.RetryerV2.RetryDelay(attempt, err)
+
+	if attemptErr, ok := errs.As[*retryAttemptError](err); ok && delayErr == nil {
+		attemptErr.state.delay = delay
+	}
+
+	return delay, delayErr
+}
+
+// classifyRetryError classifies err, assuming it is retried.
+func classifyRetryError(err error) (logging.RetryClassification, string) {
+	var code string
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		code = apiErr.ErrorCode()
+	}
+
+	throttle := retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool()
+
+	return logging.ClassifyRetry(code, throttle), code
+}
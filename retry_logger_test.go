@@ -0,0 +1,215 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+func TestRetryLogger(t *testing.T) {
+	const backoff = 50 * time.Millisecond
+
+	var mu sync.Mutex
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(throttlingResponse))
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(getCallerIdentityResponse))
+		}
+	}))
+	defer ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: newRetryDelayRecorder(retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = 5
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return backoff, nil })
+			o.RateLimiter = noRateLimiter{}
+		})),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	if _, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	retries := logger.entries("Retrying AWS API request")
+	if a, e := len(retries), 2; a != e {
+		t.Fatalf("expected %d retry log entries, got %d", e, a)
+	}
+
+	for i, expected := range []map[string]any{
+		{
+			"tf_aws.retry.attempt":            int64(2),
+			"tf_aws.retry.max_attempts":       int64(5),
+			"tf_aws.retry.remaining_attempts": int64(3),
+			"tf_aws.retry.classification":     "throttle",
+			"tf_aws.retry.error_code":         "Throttling",
+		},
+		{
+			"tf_aws.retry.attempt":            int64(3),
+			"tf_aws.retry.max_attempts":       int64(5),
+			"tf_aws.retry.remaining_attempts": int64(2),
+			"tf_aws.retry.classification":     "transient",
+			"tf_aws.retry.error_code":         "UnknownError",
+		},
+	} {
+		expected["tf_aws.retry.delay"] = backoff.Milliseconds()
+
+		if diff := cmp.Diff(expected, retries[i]); diff != "" {
+			t.Errorf("retry %d: unexpected fields (+got, -expected): %s", i+1, diff)
+		}
+	}
+
+	// Each attempt's request is logged with its attempt number
+	requestsSent := logger.entries("HTTP Request Sent")
+	if a, e := len(requestsSent), 3; a != e {
+		t.Fatalf("expected %d request log entries, got %d", e, a)
+	}
+	for i, entry := range requestsSent {
+		if a, e := entry["tf_aws.retry.attempt"], i+1; a != e {
+			t.Errorf("request %d: expected attempt %d, got %v", i+1, e, a)
+		}
+	}
+}
+
+func TestRetryLogger_maxAttempts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(throttlingResponse))
+	}))
+	defer ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The retryer is not wrapped by newRetryDelayRecorder, so the delay is not known
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = 2
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			o.RateLimiter = noRateLimiter{}
+		}),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	_, err = client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+
+	var maxAttemptsErr *retry.MaxAttemptsError
+	if !errors.As(err, &maxAttemptsErr) {
+		t.Fatalf("expected MaxAttemptsError, got %v", err)
+	}
+	if _, ok := maxAttemptsErr.Err.(*retryAttemptError); ok {
+		t.Errorf("expected the attempt error to be unwrapped, got %T", maxAttemptsErr.Err)
+	}
+
+	retries := logger.entries("Retrying AWS API request")
+	if a, e := len(retries), 1; a != e {
+		t.Fatalf("expected %d retry log entries, got %d", e, a)
+	}
+	if v, ok := retries[0]["tf_aws.retry.delay"]; ok {
+		t.Errorf("expected no delay, got %v", v)
+	}
+	if a, e := retries[0]["tf_aws.retry.attempt"], int64(2); a != e {
+		t.Errorf("expected attempt %d, got %v", e, a)
+	}
+}
+
+func TestRetryLogger_networkError(t *testing.T) {
+	// Requests to the closed server's address are refused
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	withLogger, err := withRequestResponseLogger(&Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: newRetryDelayRecorder(netretry.NewRetryer(retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = 5
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			o.RateLimiter = noRateLimiter{}
+		}), netretry.NewClassifier(1, nil))),
+		APIOptions: []func(*middleware.Stack) error{
+			withLogger,
+		},
+	})
+
+	logger := &recordingLogger{}
+	ctx := logging.RegisterLogger(context.Background(), logger)
+
+	_, err = client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+
+	var maxAttemptsErr *retry.MaxAttemptsError
+	if !errors.As(err, &maxAttemptsErr) {
+		t.Fatalf("expected MaxAttemptsError, got %v", err)
+	}
+	abandoned, ok := maxAttemptsErr.Err.(*netretry.AbandonedError)
+	if !ok {
+		t.Fatalf("expected AbandonedError, got %T", maxAttemptsErr.Err)
+	}
+	if _, ok := abandoned.Err.(*retryAttemptError); ok {
+		t.Errorf("expected the attempt error to be unwrapped, got %T", abandoned.Err)
+	}
+
+	warnings := logger.entries("Disabling retries due to networking error")
+	if a, e := len(warnings), 1; a != e {
+		t.Fatalf("expected %d warning log entries, got %d", e, a)
+	}
+	if a, e := warnings[0]["tf_aws.network_error_class"], netretry.ClassConnectionRefused; a != e {
+		t.Errorf("expected class %q, got %v", e, a)
+	}
+}
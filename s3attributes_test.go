@@ -5,6 +5,7 @@ package awsbase
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -286,6 +287,166 @@ func TestS3AttributesUploadPartInput(t *testing.T) {
 	)
 }
 
+func TestS3AttributesCopyObjectInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &s3.CopyObjectInput{
+			Bucket:     aws.String("test-bucket"),
+			Key:        aws.String("test-key"),
+			CopySource: aws.String("source-bucket/source-key?versionId=abc123"),
+		},
+	}
+
+	attributes := s3AttributeSetter(context.TODO(), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.s3.bucket", "test-bucket"),
+			attribute.String("aws.s3.key", "test-key"),
+			attribute.String("aws.s3.copy_source", "source-bucket/source-key?versionId=abc123"),
+		},
+	)
+}
+
+func TestS3AttributesUploadPartCopyInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &s3.UploadPartCopyInput{
+			Bucket:     aws.String("test-bucket"),
+			Key:        aws.String("test-key"),
+			CopySource: aws.String("source-bucket/source-key"),
+			PartNumber: aws.Int32(2),
+			UploadId:   aws.String("abcd"),
+		},
+	}
+
+	attributes := s3AttributeSetter(context.TODO(), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.s3.bucket", "test-bucket"),
+			attribute.String("aws.s3.key", "test-key"),
+			attribute.String("aws.s3.copy_source", "source-bucket/source-key"),
+			attribute.Int("aws.s3.part_number", 2),
+			attribute.String("aws.s3.upload_id", "abcd"),
+		},
+	)
+}
+
+func TestS3AttributesPutObjectTaggingInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &s3.PutObjectTaggingInput{
+			Bucket:    aws.String("test-bucket"),
+			Key:       aws.String("test-key"),
+			VersionId: aws.String("abc123"),
+			Tagging:   &s3types.Tagging{},
+		},
+	}
+
+	attributes := s3AttributeSetter(context.TODO(), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.s3.bucket", "test-bucket"),
+			attribute.String("aws.s3.key", "test-key"),
+			attribute.String("aws.s3.version_id", "abc123"),
+		},
+	)
+}
+
+func TestS3AttributesGetBucketVersioningInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &s3.GetBucketVersioningInput{
+			Bucket: aws.String("test-bucket"),
+		},
+	}
+
+	attributes := s3AttributeSetter(context.TODO(), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.s3.bucket", "test-bucket"),
+		},
+	)
+}
+
+func TestS3AttributesUnsetFields(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &s3.GetObjectInput{
+			Bucket: aws.String("test-bucket"),
+		},
+	}
+
+	attributes := s3AttributeSetter(context.TODO(), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.s3.bucket", "test-bucket"),
+		},
+	)
+}
+
+// TestS3AttributesAllInputs checks every operation of the S3 client, so that it fails if a new SDK version adds
+// an input whose bucket, key, version ID, upload ID, part number or copy source is not recorded.
+func TestS3AttributesAllInputs(t *testing.T) {
+	fields := map[string]struct {
+		value    any
+		expected attribute.KeyValue
+	}{
+		"Bucket":     {aws.String("test-bucket"), attribute.String("aws.s3.bucket", "test-bucket")},
+		"Key":        {aws.String("test-key"), attribute.String("aws.s3.key", "test-key")},
+		"VersionId":  {aws.String("abc123"), attribute.String("aws.s3.version_id", "abc123")},
+		"UploadId":   {aws.String("abcd"), attribute.String("aws.s3.upload_id", "abcd")},
+		"PartNumber": {aws.Int32(2), attribute.Int("aws.s3.part_number", 2)},
+		"CopySource": {aws.String("source-bucket/source-key"), attribute.String("aws.s3.copy_source", "source-bucket/source-key")},
+	}
+
+	contextType := reflect.TypeFor[context.Context]()
+
+	clientType := reflect.TypeFor[*s3.Client]()
+	var operations int
+	for i := range clientType.NumMethod() {
+		method := clientType.Method(i)
+
+		// Operations have the signature func(*Client, context.Context, *<Operation>Input, ...func(*Options))
+		if method.Type.NumIn() != 4 || method.Type.In(1) != contextType {
+			continue
+		}
+		inputType := method.Type.In(2)
+		if inputType.Kind() != reflect.Pointer || inputType.Elem().Name() != method.Name+"Input" {
+			continue
+		}
+		operations++
+
+		t.Run(method.Name, func(t *testing.T) {
+			input := reflect.New(inputType.Elem())
+
+			expected := []attribute.KeyValue{}
+			for name, field := range fields {
+				f := input.Elem().FieldByName(name)
+				if !f.IsValid() {
+					continue
+				}
+				value := reflect.ValueOf(field.value)
+				if !value.Type().AssignableTo(f.Type()) {
+					t.Fatalf("field %s has unsupported type %s", name, f.Type())
+				}
+				f.Set(value)
+				expected = append(expected, field.expected)
+			}
+
+			attributes := s3AttributeSetter(context.TODO(), middleware.InitializeInput{
+				Parameters: input.Interface(),
+			})
+
+			assertAttributesMatch(t, attributes, expected)
+		})
+	}
+
+	// Guards against the operations not being found, e.g. if the client's method signatures change
+	if operations < 100 {
+		t.Fatalf("expected at least 100 S3 operations, found %d", operations)
+	}
+}
+
 func TestS3AttributesSerializeDeleteShorthand(t *testing.T) {
 	testcases := map[string]struct {
 		input    *s3types.Delete
@@ -348,15 +509,15 @@ func assertAttributesMatch(t *testing.T, x, y []attribute.KeyValue) {
 	t.Helper()
 
 	if diff := cmp.Diff(x, y,
-		cmpopts.SortSlices(less[attribute.Key]),
+		cmpopts.SortSlices(lessAttributeKeyValues),
 		cmp.Comparer(compareAttributeKeyValues),
 	); diff != "" {
 		t.Fatalf("unexpected credentials: (- got, + expected)\n%s", diff)
 	}
 }
 
-func less[T ~string](x, y T) bool {
-	return x < y
+func lessAttributeKeyValues(x, y attribute.KeyValue) bool {
+	return x.Key < y.Key
 }
 
 func compareAttributeKeyValues(x, y attribute.KeyValue) bool {
@@ -366,5 +527,5 @@ func compareAttributeKeyValues(x, y attribute.KeyValue) bool {
 	if x.Value.Type() != y.Value.Type() {
 		return false
 	}
-	return x.Value.AsInterface() == y.Value.AsInterface()
+	return cmp.Equal(x.Value.AsInterface(), y.Value.AsInterface())
 }
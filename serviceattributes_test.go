@@ -0,0 +1,262 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestServiceAttributesLambdaInvokeInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &lambda.InvokeInput{
+			FunctionName: aws.String("test-function"),
+			Qualifier:    aws.String("live"),
+			Payload:      []byte(`{}`),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(lambda.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.lambda.function_name", "test-function"),
+			attribute.String("aws.lambda.qualifier", "live"),
+		},
+	)
+}
+
+func TestServiceAttributesLambdaGetFunctionInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &lambda.GetFunctionInput{
+			FunctionName: aws.String("test-function"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(lambda.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.lambda.function_name", "test-function"),
+		},
+	)
+}
+
+func TestServiceAttributesSNSPublishInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &sns.PublishInput{
+			TopicArn: aws.String("arn:aws:sns:us-east-1:123456789012:test-topic"),
+			Message:  aws.String("test message"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(sns.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.sns.topic_arn", "arn:aws:sns:us-east-1:123456789012:test-topic"),
+		},
+	)
+}
+
+func TestServiceAttributesKMSDescribeKeyInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &kms.DescribeKeyInput{
+			KeyId: aws.String("alias/test-key"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(kms.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.kms.key_id", "alias/test-key"),
+		},
+	)
+}
+
+func TestServiceAttributesSecretsManagerGetSecretValueInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String("test-secret"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(secretsmanager.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.secretsmanager.secret_id", "test-secret"),
+		},
+	)
+}
+
+func TestServiceAttributesEC2DescribeInstancesInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &ec2.DescribeInstancesInput{
+			InstanceIds: []string{"i-1234567890abcdef0", "i-0fedcba0987654321"},
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(ec2.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.StringSlice("aws.ec2.instance_ids", []string{"i-1234567890abcdef0", "i-0fedcba0987654321"}),
+		},
+	)
+}
+
+func TestServiceAttributesEC2DescribeVpcsInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &ec2.DescribeVpcsInput{
+			VpcIds: []string{"vpc-1234567890abcdef0"},
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(ec2.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.StringSlice("aws.ec2.vpc_ids", []string{"vpc-1234567890abcdef0"}),
+		},
+	)
+}
+
+func TestServiceAttributesEC2CreateSubnetInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &ec2.CreateSubnetInput{
+			VpcId:     aws.String("vpc-1234567890abcdef0"),
+			CidrBlock: aws.String("10.0.1.0/24"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(ec2.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.ec2.vpc_id", "vpc-1234567890abcdef0"),
+		},
+	)
+}
+
+func TestServiceAttributesEC2ModifyInstanceAttributeInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &ec2.ModifyInstanceAttributeInput{
+			InstanceId: aws.String("i-1234567890abcdef0"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(ec2.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.ec2.instance_id", "i-1234567890abcdef0"),
+		},
+	)
+}
+
+func TestServiceAttributesIAMGetRoleInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &iam.GetRoleInput{
+			RoleName: aws.String("test-role"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(iam.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.iam.role_name", "test-role"),
+		},
+	)
+}
+
+func TestServiceAttributesIAMAddUserToGroupInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &iam.AddUserToGroupInput{
+			UserName:  aws.String("test-user"),
+			GroupName: aws.String("test-group"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(iam.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.iam.user_name", "test-user"),
+		},
+	)
+}
+
+func TestServiceAttributesCloudFormationDescribeStacksInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &cloudformation.DescribeStacksInput{
+			StackName: aws.String("test-stack"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(cloudformation.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.cloudformation.stack_name", "test-stack"),
+		},
+	)
+}
+
+func TestServiceAttributesEventBridgePutRuleInput(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &eventbridge.PutRuleInput{
+			Name:         aws.String("test-rule"),
+			EventBusName: aws.String("test-bus"),
+		},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(eventbridge.ServiceID), input)
+
+	assertAttributesMatch(t, attributes,
+		[]attribute.KeyValue{
+			attribute.String("aws.eventbridge.event_bus_name", "test-bus"),
+		},
+	)
+}
+
+func TestServiceAttributesUnsetFields(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &lambda.ListFunctionsInput{},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(lambda.ServiceID), input)
+
+	assertAttributesMatch(t, attributes, nil)
+}
+
+func TestServiceAttributesUnsupportedService(t *testing.T) {
+	input := middleware.InitializeInput{
+		Parameters: &sts.GetCallerIdentityInput{},
+	}
+
+	attributes := serviceAttributeSetter(serviceIDContext(sts.ServiceID), input)
+
+	assertAttributesMatch(t, attributes, nil)
+}
+
+func serviceIDContext(serviceID string) context.Context {
+	return awsmiddleware.SetServiceID(context.Background(), serviceID)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package tfawserr provides helpers for matching errors returned by the AWS SDK for Go v2, equivalent to those in
+// the awsv1shim tfawserr package for the AWS SDK for Go v1.
+package tfawserr
+
+import (
+	"strings"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
+)
+
+// The code and message of the AWS SDK for Go v1 error for a request that could not be sent
+const (
+	requestErrorCode    = "RequestError"
+	requestErrorMessage = "send request failed"
+)
+
+// ErrMessageAndOrigErrContain returns true if the error matches all these conditions:
+//   - err is or wraps a smithy.APIError
+//   - APIError.ErrorCode() matches code
+//   - APIError.ErrorMessage() contains message
+//   - the cause of the failure contains origErrMessage, see OrigErrMessage
+//
+// A request that could not be sent, such as because of a network error, has no smithy.APIError. It matches as the
+// AWS SDK for Go v1 reports it: with the code "RequestError", the message "send request failed" and the send
+// failure as the cause.
+func ErrMessageAndOrigErrContain(err error, code string, message string, origErrMessage string) bool {
+	if sendErr, ok := errs.As[*smithyhttp.RequestSendError](err); ok {
+		return code == requestErrorCode &&
+			strings.Contains(requestErrorMessage, message) &&
+			strings.Contains(sendErr.Err.Error(), origErrMessage)
+	}
+
+	if !ErrMessageContains(err, code, message) {
+		return false
+	}
+
+	if origErrMessage == "" {
+		return true
+	}
+
+	if cause := causeError(err); cause != nil {
+		return strings.Contains(cause.Error(), origErrMessage)
+	}
+
+	return false
+}
+
+// ErrCodeEquals returns true if the error matches all these conditions:
+//   - err is or wraps a smithy.APIError
+//   - APIError.ErrorCode() equals one of the passed codes
+func ErrCodeEquals(err error, codes ...string) bool {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		for _, code := range codes {
+			if apiErr.ErrorCode() == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ErrCodeContains returns true if the error matches all these conditions:
+//   - err is or wraps a smithy.APIError
+//   - APIError.ErrorCode() contains code
+func ErrCodeContains(err error, code string) bool {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		return strings.Contains(apiErr.ErrorCode(), code)
+	}
+	return false
+}
+
+// ErrMessageContains returns true if the error matches all these conditions:
+//   - err is or wraps a smithy.APIError
+//   - APIError.ErrorCode() equals code
+//   - APIError.ErrorMessage() contains message
+func ErrMessageContains(err error, code string, message string) bool {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		return apiErr.ErrorCode() == code && strings.Contains(apiErr.ErrorMessage(), message)
+	}
+	return false
+}
+
+// httpStatusCodeError is implemented by *awshttp.ResponseError and *smithyhttp.ResponseError
+type httpStatusCodeError interface {
+	error
+	HTTPStatusCode() int
+}
+
+// causeError returns the cause of a failed API call: the error wrapped by the HTTP response error or, if there was
+// no response, by the smithy.OperationError. An API error wrapped directly by the response error has no other cause,
+// so nil is returned, as for an awserr.Error without an OrigErr() in the AWS SDK for Go v1.
+func causeError(err error) error {
+	var cause error
+	if respErr, ok := errs.As[*awshttp.ResponseError](err); ok {
+		cause = respErr.Err
+	} else if respErr, ok := errs.As[*smithyhttp.ResponseError](err); ok {
+		cause = respErr.Err
+	} else if opErr, ok := errs.As[*smithy.OperationError](err); ok {
+		cause = opErr.Err
+	}
+
+	if _, ok := cause.(smithy.APIError); ok {
+		return nil
+	}
+	return cause
+}
+
+// ErrStatusCodeEquals returns true if the error matches all these conditions:
+//   - err is or wraps an HTTP response error, such as *awshttp.ResponseError
+//   - ResponseError.HTTPStatusCode() equals statusCode
+//
+// It is always preferable to use ErrMessageContains() except in older APIs (e.g. S3)
+// that sometimes only respond with status codes.
+func ErrStatusCodeEquals(err error, statusCode int) bool {
+	if respErr, ok := errs.As[httpStatusCodeError](err); ok {
+		return respErr.HTTPStatusCode() == statusCode
+	}
+	return false
+}
@@ -0,0 +1,845 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestErrMessageAndOrigErrContain(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Err             error
+		Code            string
+		Message         string
+		ExtendedMessage string
+		Expected        bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name: "nil error code",
+			Err:  nil,
+			Code: "test",
+		},
+		{
+			Name:    "nil error message",
+			Err:     nil,
+			Message: "test",
+		},
+		{
+			Name:    "nil error code and message",
+			Err:     nil,
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name:            "nil error code, message, and extended message",
+			Err:             nil,
+			Code:            "test",
+			Message:         "test",
+			ExtendedMessage: "test",
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name: "other error code",
+			Err:  errors.New("test"),
+			Code: "test",
+		},
+		{
+			Name:    "other error message",
+			Err:     errors.New("test"),
+			Message: "test",
+		},
+		{
+			Name:    "other error code and message",
+			Err:     errors.New("test"),
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name:            "other error code, message, and extended message",
+			Err:             errors.New("test"),
+			Code:            "test",
+			Message:         "test",
+			ExtendedMessage: "test",
+		},
+		{
+			Name:     "API error matching code, no message, and no extended message",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:            "API error matching code, no message, and extended message",
+			Err:             apiError("TestCode", "TestMessage"),
+			Code:            "TestCode",
+			ExtendedMessage: "TestExtendedMessage",
+		},
+		{
+			Name:     "API error matching code, matching message exact, and no extended message",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+		{
+			Name:            "API error matching code, matching message exact, and extended message",
+			Err:             apiError("TestCode", "TestMessage"),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "TestExtendedMessage",
+		},
+		{
+			Name:     "API error matching code, matching message contains, and no extended message",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:            "API error matching code, matching message contains, and extended message",
+			Err:             apiError("TestCode", "TestMessage"),
+			Code:            "TestCode",
+			Message:         "Message",
+			ExtendedMessage: "Message",
+		},
+		{
+			Name:    "API error matching code, non-matching message, and no extended message",
+			Err:     apiError("TestCode", "TestMessage"),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+		{
+			Name: "API error no code, no message, and no extended message",
+			Err:  apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:    "API error no code, matching message exact, and no extended message",
+			Err:     apiError("TestCode", "TestMessage"),
+			Message: "TestMessage",
+		},
+		{
+			Name: "API error non-matching code, no message, and no extended message",
+			Err:  apiError("TestCode", "TestMessage"),
+			Code: "NotMatching",
+		},
+		{
+			Name:    "API error non-matching code, matching message exact, and no extended message",
+			Err:     apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:    "NonMatching",
+			Message: "TestMessage",
+		},
+		{
+			Name:     "API error with cause matching code, no message, and no extended message",
+			Err:      apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:            "API error with cause matching code, no message, and matching extended message exact",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			ExtendedMessage: "TestExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:            "API error with cause matching code, no message, and matching extended message contains",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			ExtendedMessage: "ExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:     "API error with cause matching code, matching message exact, and no extended message",
+			Err:      apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+		{
+			Name:            "API error with cause matching code, matching message exact, and matching extended message exact",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "TestExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:            "API error with cause matching code, matching message exact, and matching extended message contains",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "ExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:     "API error with cause matching code, matching message contains, and no extended message",
+			Err:      apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:            "API error with cause matching code, matching message contains, and matching extended message contains",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			Message:         "Message",
+			ExtendedMessage: "ExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:    "API error with cause matching code, non-matching message, and no extended message",
+			Err:     apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+		{
+			Name: "API error with cause no code, no message, and no extended message",
+			Err:  apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+		},
+		{
+			Name:    "API error with cause no code, matching message exact, and no extended message",
+			Err:     apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Message: "TestMessage",
+		},
+		{
+			Name: "API error with cause non-matching code, no message, and no extended message",
+			Err:  apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code: "NotMatching",
+		},
+		{
+			Name:    "API error with cause non-matching code, matching message exact, and no extended message",
+			Err:     apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:    "NonMatching",
+			Message: "TestMessage",
+		},
+		{
+			Name:            "operation error with cause matching code, matching message exact, and matching extended message exact",
+			Err:             apiErrorWithCause("TestCode", "TestMessage", errors.New("TestExtendedMessage")),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "TestExtendedMessage",
+			Expected:        true,
+		},
+		{
+			Name:            "operation error matching code, matching message exact, and extended message",
+			Err:             operationError(apiError("TestCode", "TestMessage")),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "TestExtendedMessage",
+		},
+		{
+			Name:            "response error matching code, matching message exact, and extended message in API error",
+			Err:             operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:            "TestCode",
+			Message:         "TestMessage",
+			ExtendedMessage: "TestMessage",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrMessageAndOrigErrContain(testCase.Err, testCase.Code, testCase.Message, testCase.ExtendedMessage)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrMessageAndOrigErrContain_sendFailure(t *testing.T) {
+	// Reserve a port with nothing listening on it
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %s", err)
+	}
+	endpoint := "http://" + l.Addr().String()
+	l.Close()
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials:  aws.AnonymousCredentials{},
+		Retryer:      aws.NopRetryer{},
+	})
+
+	_, err = client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	testCases := map[string]struct {
+		Code            string
+		Message         string
+		ExtendedMessage string
+		Expected        bool
+	}{
+		"matching code, message and extended message": {
+			Code:            "RequestError",
+			Message:         "send request failed",
+			ExtendedMessage: "connection refused",
+			Expected:        true,
+		},
+		"matching code and no extended message": {
+			Code:     "RequestError",
+			Expected: true,
+		},
+		"non-matching code": {
+			Code:            "NotMatching",
+			ExtendedMessage: "connection refused",
+		},
+		"non-matching message": {
+			Code:    "RequestError",
+			Message: "NotMatching",
+		},
+		"non-matching extended message": {
+			Code:            "RequestError",
+			ExtendedMessage: "NotMatching",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := ErrMessageAndOrigErrContain(err, testCase.Code, testCase.Message, testCase.ExtendedMessage)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t for %q", got, testCase.Expected, err)
+			}
+		})
+	}
+}
+
+func TestErrCodeEquals(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Codes    []string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name:  "nil error code",
+			Err:   nil,
+			Codes: []string{"test"},
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name:  "other error code",
+			Err:   errors.New("test"),
+			Codes: []string{"test"},
+		},
+		{
+			Name:     "API error matching first code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Codes:    []string{"TestCode"},
+			Expected: true,
+		},
+		{
+			Name:     "API error matching last code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Codes:    []string{"NotMatching", "TestCode"},
+			Expected: true,
+		},
+		{
+			Name: "API error no code",
+			Err:  apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:  "API error non-matching code",
+			Err:   apiError("TestCode", "TestMessage"),
+			Codes: []string{"NotMatching"},
+		},
+		{
+			Name:  "API error non-matching codes",
+			Err:   apiError("TestCode", "TestMessage"),
+			Codes: []string{"NotMatching", "AlsoNotMatching"},
+		},
+		{
+			Name: "wrapped other error",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+		},
+		{
+			Name:  "wrapped other error code",
+			Err:   fmt.Errorf("test: %w", errors.New("test")),
+			Codes: []string{"test"},
+		},
+		{
+			Name:     "wrapped API error matching first code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes:    []string{"TestCode", "NotMatching"},
+			Expected: true,
+		},
+		{
+			Name:     "wrapped API error matching last code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes:    []string{"NotMatching", "TestCode"},
+			Expected: true,
+		},
+		{
+			Name: "wrapped API error no code",
+			Err:  fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+		},
+		{
+			Name:  "wrapped API error non-matching code",
+			Err:   fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes: []string{"NotMatching"},
+		},
+		{
+			Name:  "wrapped API error non-matching codes",
+			Err:   fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes: []string{"NotMatching", "AlsoNotMatching"},
+		},
+		{
+			Name:     "operation error matching code",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Codes:    []string{"TestCode"},
+			Expected: true,
+		},
+		{
+			Name:  "operation error non-matching code",
+			Err:   operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Codes: []string{"NotMatching"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrCodeEquals(testCase.Err, testCase.Codes...)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrCodeContains(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Code     string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name: "nil error code",
+			Err:  nil,
+			Code: "test",
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name: "other error code",
+			Err:  errors.New("test"),
+			Code: "test",
+		},
+		{
+			Name:     "API error matching code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:     "API error contains code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "Test",
+			Expected: true,
+		},
+		{
+			Name:     "API error no code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Expected: true,
+		},
+		{
+			Name: "API error non-matching code",
+			Err:  apiError("TestCode", "TestMessage"),
+			Code: "NotMatching",
+		},
+		{
+			Name: "wrapped other error",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+		},
+		{
+			Name: "wrapped other error code",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+			Code: "test",
+		},
+		{
+			Name:     "wrapped API error matching code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped API error contains code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "Test",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped API error no code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Expected: true,
+		},
+		{
+			Name: "wrapped API error non-matching code",
+			Err:  fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code: "NotMatching",
+		},
+		{
+			Name:     "operation error contains code",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:     "Test",
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrCodeContains(testCase.Err, testCase.Code)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrMessageContains(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Code     string
+		Message  string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name: "nil error code",
+			Err:  nil,
+			Code: "test",
+		},
+		{
+			Name:    "nil error message",
+			Err:     nil,
+			Message: "test",
+		},
+		{
+			Name:    "nil error code and message",
+			Err:     nil,
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name: "other error code",
+			Err:  errors.New("test"),
+			Code: "test",
+		},
+		{
+			Name:    "other error message",
+			Err:     errors.New("test"),
+			Message: "test",
+		},
+		{
+			Name:    "other error code and message",
+			Err:     errors.New("test"),
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name:     "API error matching code and no message",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:     "API error matching code and matching message exact",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+		{
+			Name:     "API error matching code and matching message contains",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:    "API error matching code and non-matching message",
+			Err:     apiError("TestCode", "TestMessage"),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+		{
+			Name: "API error no code",
+			Err:  apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:    "API error no code and matching message exact",
+			Err:     apiError("TestCode", "TestMessage"),
+			Message: "TestMessage",
+		},
+		{
+			Name: "API error non-matching code",
+			Err:  apiError("TestCode", "TestMessage"),
+			Code: "NotMatching",
+		},
+		{
+			Name:    "API error non-matching code and message exact",
+			Err:     apiError("TestCode", "TestMessage"),
+			Message: "TestMessage",
+		},
+		{
+			Name: "wrapped other error",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+		},
+		{
+			Name: "wrapped other error code",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+			Code: "test",
+		},
+		{
+			Name:    "wrapped other error message",
+			Err:     fmt.Errorf("test: %w", errors.New("test")),
+			Message: "test",
+		},
+		{
+			Name:    "wrapped other error code and message",
+			Err:     fmt.Errorf("test: %w", errors.New("test")),
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name:     "wrapped API error matching code and no message",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped API error matching code and matching message exact",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped API error matching code and matching message contains",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:    "wrapped API error matching code and non-matching message",
+			Err:     fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+		{
+			Name: "wrapped API error no code",
+			Err:  fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+		},
+		{
+			Name:    "wrapped API error no code and matching message exact",
+			Err:     fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Message: "TestMessage",
+		},
+		{
+			Name: "wrapped API error non-matching code",
+			Err:  fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code: "NotMatching",
+		},
+		{
+			Name:    "wrapped API error non-matching code and message exact",
+			Err:     fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Message: "TestMessage",
+		},
+		{
+			Name:     "operation error matching code and matching message contains",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:    "operation error matching code and non-matching message",
+			Err:     operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrMessageContains(testCase.Err, testCase.Code, testCase.Message)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrStatusCodeEquals(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Err        error
+		StatusCode int
+		Expected   bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name:       "nil error status code",
+			Err:        nil,
+			StatusCode: 42,
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name:       "other error status code",
+			Err:        errors.New("test"),
+			StatusCode: 42,
+		},
+		{
+			Name:       "API error matching status code",
+			Err:        responseError(apiError("TestCode", "TestMessage"), 42),
+			StatusCode: 42,
+			Expected:   true,
+		},
+		{
+			Name:       "API error non-matching statuc code",
+			Err:        responseError(apiError("TestCode", "TestMessage"), 404),
+			StatusCode: 42,
+		},
+		{
+			Name: "wrapped other error",
+			Err:  fmt.Errorf("test: %w", errors.New("test")),
+		},
+		{
+			Name:       "wrapped other status code",
+			Err:        fmt.Errorf("test: %w", errors.New("test")),
+			StatusCode: 42,
+		},
+		{
+			Name:       "wrapped API error matching status code",
+			Err:        fmt.Errorf("test: %w", responseError(apiError("TestCode", "TestMessage"), 42)),
+			StatusCode: 42,
+			Expected:   true,
+		},
+		{
+			Name:       "wrapped API error non-matching status code",
+			Err:        fmt.Errorf("test: %w", responseError(apiError("TestCode", "TestMessage"), 404)),
+			StatusCode: 42,
+		},
+		{
+			Name:       "operation error matching status code",
+			Err:        operationError(responseError(apiError("TestCode", "TestMessage"), 404)),
+			StatusCode: 404,
+			Expected:   true,
+		},
+		{
+			Name:       "operation error without response",
+			Err:        operationError(apiError("TestCode", "TestMessage")),
+			StatusCode: 404,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrStatusCodeEquals(testCase.Err, testCase.StatusCode)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func apiError(code, message string) error {
+	return &smithy.GenericAPIError{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// apiErrorWithCause returns a failed API call whose response error wraps cause alongside the API error,
+// equivalent to an awserr.Error with an OrigErr
+func apiErrorWithCause(code, message string, cause error) error {
+	return operationError(responseError(errors.Join(apiError(code, message), cause), http.StatusBadRequest))
+}
+
+func responseError(err error, statusCode int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{
+				Response: &http.Response{
+					StatusCode: statusCode,
+				},
+			},
+			Err: err,
+		},
+		RequestID: "01234567-89ab-cdef-0123-456789abcdef",
+	}
+}
+
+func operationError(err error) error {
+	return &smithy.OperationError{
+		ServiceID:     "Test",
+		OperationName: "Test",
+		Err:           err,
+	}
+}
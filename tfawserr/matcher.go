@@ -0,0 +1,276 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/errs"
+)
+
+// Matcher matches errors returned by the AWS SDK for Go v1 or v2. Matchers are combined using And, Or and Not,
+// and can be named with Named to build reusable sets such as NotFound and Throttling.
+//
+// The zero value matches no error.
+type Matcher struct {
+	description string
+	match       func(err error) (reason string, ok bool)
+}
+
+// Matches returns true if err matches. A nil error never matches.
+func (m Matcher) Matches(err error) bool {
+	_, ok := m.Explain(err)
+	return ok
+}
+
+// Explain returns true if err matches, together with the reason, suitable for logging.
+func (m Matcher) Explain(err error) (string, bool) {
+	if err == nil || m.match == nil {
+		return "", false
+	}
+	return m.match(err)
+}
+
+// String describes the errors matched.
+func (m Matcher) String() string {
+	return m.description
+}
+
+// Named returns a Matcher that matches the same errors as m, with name prefixed to its description and reasons.
+func (m Matcher) Named(name string) Matcher {
+	return Matcher{
+		description: name,
+		match: func(err error) (string, bool) {
+			reason, ok := m.Explain(err)
+			if !ok {
+				return "", false
+			}
+			return fmt.Sprintf("%s: %s", name, reason), true
+		},
+	}
+}
+
+// Code matches errors whose error code equals one of codes.
+func Code(codes ...string) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("error code in %s", quoteAll(codes)),
+		match: func(err error) (string, bool) {
+			code, ok := errorCode(err)
+			if !ok || !slices.Contains(codes, code) {
+				return "", false
+			}
+			return fmt.Sprintf("error code is %q", code), true
+		},
+	}
+}
+
+// Message matches errors whose error message matches the regular expression expr.
+// It panics if expr cannot be compiled.
+func Message(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+
+	return Matcher{
+		description: fmt.Sprintf("error message matches %q", expr),
+		match: func(err error) (string, bool) {
+			message, ok := errorMessage(err)
+			if !ok || !re.MatchString(message) {
+				return "", false
+			}
+			return fmt.Sprintf("error message %q matches %q", message, expr), true
+		},
+	}
+}
+
+// StatusCode matches errors whose HTTP status code equals one of statusCodes.
+func StatusCode(statusCodes ...int) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("HTTP status code in %v", statusCodes),
+		match: func(err error) (string, bool) {
+			statusCode, ok := httpStatusCode(err)
+			if !ok || !slices.Contains(statusCodes, statusCode) {
+				return "", false
+			}
+			return fmt.Sprintf("HTTP status code is %d", statusCode), true
+		},
+	}
+}
+
+// OrigErrMessage matches errors whose origin error message matches the regular expression expr.
+// The origin error is awserr.Error.OrigErr() for the AWS SDK for Go v1. For v2, it is the error wrapped by the HTTP
+// response error or, if there was no response, by the smithy.OperationError; an API error returned by the service
+// has no origin error. It panics if expr cannot be compiled.
+func OrigErrMessage(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+
+	return Matcher{
+		description: fmt.Sprintf("origin error matches %q", expr),
+		match: func(err error) (string, bool) {
+			origErr := originError(err)
+			if origErr == nil || !re.MatchString(origErr.Error()) {
+				return "", false
+			}
+			return fmt.Sprintf("origin error %q matches %q", origErr, expr), true
+		},
+	}
+}
+
+// OrigErrIs matches errors whose origin error is, or wraps, target. See OrigErrMessage.
+func OrigErrIs(target error) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("origin error is %q", target),
+		match: func(err error) (string, bool) {
+			origErr := originError(err)
+			if origErr == nil || !errors.Is(origErr, target) {
+				return "", false
+			}
+			return fmt.Sprintf("origin error is %q", target), true
+		},
+	}
+}
+
+// And matches errors matched by all of matchers.
+func And(matchers ...Matcher) Matcher {
+	return Matcher{
+		description: combine(" and ", matchers),
+		match: func(err error) (string, bool) {
+			reasons := make([]string, 0, len(matchers))
+			for _, m := range matchers {
+				reason, ok := m.Explain(err)
+				if !ok {
+					return "", false
+				}
+				reasons = append(reasons, reason)
+			}
+			return strings.Join(reasons, " and "), len(reasons) > 0
+		},
+	}
+}
+
+// Or matches errors matched by any of matchers. The reason is that of the first matching matcher.
+func Or(matchers ...Matcher) Matcher {
+	return Matcher{
+		description: combine(" or ", matchers),
+		match: func(err error) (string, bool) {
+			for _, m := range matchers {
+				if reason, ok := m.Explain(err); ok {
+					return reason, true
+				}
+			}
+			return "", false
+		},
+	}
+}
+
+// Not matches errors not matched by m.
+func Not(m Matcher) Matcher {
+	description := fmt.Sprintf("not (%s)", m)
+
+	return Matcher{
+		description: description,
+		match: func(err error) (string, bool) {
+			if m.Matches(err) {
+				return "", false
+			}
+			return description, true
+		},
+	}
+}
+
+var (
+	// NotFound matches errors returned when a resource does not exist.
+	NotFound = Or(
+		Code(
+			"NoSuchBucket",
+			"NoSuchEntity",
+			"NoSuchKey",
+			"NotFound",
+			"NotFoundException",
+			"ResourceNotFoundException",
+		),
+		StatusCode(http.StatusNotFound),
+	).Named("NotFound")
+
+	// Throttling matches errors returned when requests are throttled, using the throttling error codes of the AWS SDK
+	// for Go v2 retryer.
+	Throttling = Or(
+		Code(slices.Sorted(maps.Keys(retry.DefaultThrottleErrorCodes))...),
+		StatusCode(http.StatusTooManyRequests),
+	).Named("Throttling")
+)
+
+// awsError matches awserr.Error from the AWS SDK for Go v1, without depending on it
+type awsError interface {
+	error
+	Code() string
+	Message() string
+	OrigErr() error
+}
+
+func errorCode(err error) (string, bool) {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		return apiErr.ErrorCode(), true
+	}
+	if awsErr, ok := errs.As[awsError](err); ok {
+		return awsErr.Code(), true
+	}
+	return "", false
+}
+
+func errorMessage(err error) (string, bool) {
+	if apiErr, ok := errs.As[smithy.APIError](err); ok {
+		return apiErr.ErrorMessage(), true
+	}
+	if awsErr, ok := errs.As[awsError](err); ok {
+		return awsErr.Message(), true
+	}
+	return "", false
+}
+
+// requestFailure matches awserr.RequestFailure from the AWS SDK for Go v1
+type requestFailure interface {
+	error
+	StatusCode() int
+}
+
+func httpStatusCode(err error) (int, bool) {
+	if respErr, ok := errs.As[httpStatusCodeError](err); ok {
+		return respErr.HTTPStatusCode(), true
+	}
+	if reqErr, ok := errs.As[requestFailure](err); ok {
+		return reqErr.StatusCode(), true
+	}
+	return 0, false
+}
+
+func originError(err error) error {
+	if awsErr, ok := errs.As[awsError](err); ok {
+		return awsErr.OrigErr()
+	}
+	return causeError(err)
+}
+
+func combine(sep string, matchers []Matcher) string {
+	descriptions := make([]string, len(matchers))
+	for i, m := range matchers {
+		descriptions[i] = "(" + m.String() + ")"
+	}
+	return strings.Join(descriptions, sep)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, " ") + "]"
+}
@@ -0,0 +1,325 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestMatcher(t *testing.T) {
+	errTest := errors.New("test")
+
+	testCases := []struct {
+		Name           string
+		Matcher        Matcher
+		Err            error
+		Expected       bool
+		ExpectedReason string
+	}{
+		{
+			Name:    "nil error",
+			Matcher: Code("TestCode"),
+			Err:     nil,
+		},
+		{
+			Name:    "zero value",
+			Matcher: Matcher{},
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:    "code other error",
+			Matcher: Code("TestCode"),
+			Err:     errTest,
+		},
+		{
+			Name:           "code API error",
+			Matcher:        Code("OtherCode", "TestCode"),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `error code is "TestCode"`,
+		},
+		{
+			Name:    "code API error non-matching",
+			Matcher: Code("OtherCode"),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "code operation error",
+			Matcher:        Code("TestCode"),
+			Err:            operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Expected:       true,
+			ExpectedReason: `error code is "TestCode"`,
+		},
+		{
+			Name:           "code v1 error",
+			Matcher:        Code("TestCode"),
+			Err:            fmt.Errorf("test: %w", v1Error("TestCode", "TestMessage", nil, 400)),
+			Expected:       true,
+			ExpectedReason: `error code is "TestCode"`,
+		},
+		{
+			Name:           "message API error",
+			Matcher:        Message(`^Test\w+$`),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `error message "TestMessage" matches "^Test\\w+$"`,
+		},
+		{
+			Name:    "message API error non-matching",
+			Matcher: Message(`^Other`),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "message v1 error",
+			Matcher:        Message(`Message`),
+			Err:            v1Error("TestCode", "TestMessage", nil, 400),
+			Expected:       true,
+			ExpectedReason: `error message "TestMessage" matches "Message"`,
+		},
+		{
+			Name:           "status code API error",
+			Matcher:        StatusCode(404),
+			Err:            operationError(responseError(apiError("TestCode", "TestMessage"), 404)),
+			Expected:       true,
+			ExpectedReason: "HTTP status code is 404",
+		},
+		{
+			Name:    "status code API error without response",
+			Matcher: StatusCode(404),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "status code v1 error",
+			Matcher:        StatusCode(400, 404),
+			Err:            v1Error("TestCode", "TestMessage", nil, 404),
+			Expected:       true,
+			ExpectedReason: "HTTP status code is 404",
+		},
+		{
+			Name:           "origin error message deserialization error",
+			Matcher:        OrigErrMessage(`test$`),
+			Err:            operationError(responseError(&smithy.DeserializationError{Err: errTest}, 500)),
+			Expected:       true,
+			ExpectedReason: `origin error "deserialization failed, test" matches "test$"`,
+		},
+		{
+			Name:    "origin error message API error without origin error",
+			Matcher: OrigErrMessage(`.*`),
+			Err:     operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+		},
+		{
+			Name:           "origin error is API error",
+			Matcher:        OrigErrIs(errTest),
+			Err:            apiErrorWithCause("TestCode", "TestMessage", errTest),
+			Expected:       true,
+			ExpectedReason: `origin error is "test"`,
+		},
+		{
+			Name:           "origin error is request send error",
+			Matcher:        OrigErrIs(errTest),
+			Err:            operationError(&smithyhttp.RequestSendError{Err: errTest}),
+			Expected:       true,
+			ExpectedReason: `origin error is "test"`,
+		},
+		{
+			Name:           "origin error is v1 error",
+			Matcher:        OrigErrIs(errTest),
+			Err:            v1Error("TestCode", "TestMessage", fmt.Errorf("wrapped: %w", errTest), 400),
+			Expected:       true,
+			ExpectedReason: `origin error is "test"`,
+		},
+		{
+			Name:    "origin error is v1 error non-matching",
+			Matcher: OrigErrIs(errTest),
+			Err:     v1Error("TestCode", "TestMessage", errors.New("other"), 400),
+		},
+		{
+			Name:           "and",
+			Matcher:        And(Code("TestCode"), Message(`Message`)),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `error code is "TestCode" and error message "TestMessage" matches "Message"`,
+		},
+		{
+			Name:    "and non-matching",
+			Matcher: And(Code("TestCode"), Message(`^Other`)),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:    "and empty",
+			Matcher: And(),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "or",
+			Matcher:        Or(Code("OtherCode"), Message(`Message`)),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `error message "TestMessage" matches "Message"`,
+		},
+		{
+			Name:    "or non-matching",
+			Matcher: Or(Code("OtherCode"), Message(`^Other`)),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "not",
+			Matcher:        Not(Code("OtherCode")),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `not (error code in ["OtherCode"])`,
+		},
+		{
+			Name:    "not non-matching",
+			Matcher: Not(Code("TestCode")),
+			Err:     apiError("TestCode", "TestMessage"),
+		},
+		{
+			Name:           "named",
+			Matcher:        Code("TestCode").Named("Test"),
+			Err:            apiError("TestCode", "TestMessage"),
+			Expected:       true,
+			ExpectedReason: `Test: error code is "TestCode"`,
+		},
+		{
+			Name:           "NotFound code",
+			Matcher:        NotFound,
+			Err:            operationError(responseError(apiError("NoSuchEntity", "TestMessage"), 400)),
+			Expected:       true,
+			ExpectedReason: `NotFound: error code is "NoSuchEntity"`,
+		},
+		{
+			Name:           "NotFound status code",
+			Matcher:        NotFound,
+			Err:            v1Error("TestCode", "TestMessage", nil, 404),
+			Expected:       true,
+			ExpectedReason: "NotFound: HTTP status code is 404",
+		},
+		{
+			Name:    "NotFound non-matching",
+			Matcher: NotFound,
+			Err:     operationError(responseError(apiError("AccessDenied", "TestMessage"), 403)),
+		},
+		{
+			Name:           "Throttling code",
+			Matcher:        Throttling,
+			Err:            v1Error("ThrottlingException", "Rate exceeded", nil, 400),
+			Expected:       true,
+			ExpectedReason: `Throttling: error code is "ThrottlingException"`,
+		},
+		{
+			Name:           "Throttling status code",
+			Matcher:        Throttling,
+			Err:            operationError(responseError(apiError("TestCode", "TestMessage"), 429)),
+			Expected:       true,
+			ExpectedReason: "Throttling: HTTP status code is 429",
+		},
+		{
+			Name:    "Throttling non-matching",
+			Matcher: Throttling,
+			Err:     operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+		},
+		{
+			Name:           "named sets combined",
+			Matcher:        And(Not(NotFound), Not(Throttling), Code("AccessDenied")),
+			Err:            operationError(responseError(apiError("AccessDenied", "TestMessage"), 403)),
+			Expected:       true,
+			ExpectedReason: `not (NotFound) and not (Throttling) and error code is "AccessDenied"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			if got := testCase.Matcher.Matches(testCase.Err); got != testCase.Expected {
+				t.Errorf("Matches: got %t, expected %t", got, testCase.Expected)
+			}
+
+			reason, ok := testCase.Matcher.Explain(testCase.Err)
+			if ok != testCase.Expected {
+				t.Errorf("Explain: got %t, expected %t", ok, testCase.Expected)
+			}
+			if reason != testCase.ExpectedReason {
+				t.Errorf("Explain: got reason %q, expected %q", reason, testCase.ExpectedReason)
+			}
+		})
+	}
+}
+
+func TestMatcherString(t *testing.T) {
+	testCases := map[string]struct {
+		Matcher  Matcher
+		Expected string
+	}{
+		"code": {
+			Matcher:  Code("TestCode", "OtherCode"),
+			Expected: `error code in ["TestCode" "OtherCode"]`,
+		},
+		"combined": {
+			Matcher:  Or(And(Code("TestCode"), StatusCode(400)), Not(Message(`^Test`))),
+			Expected: `((error code in ["TestCode"]) and (HTTP status code in [400])) or (not (error message matches "^Test"))`,
+		},
+		"named": {
+			Matcher:  And(NotFound, OrigErrMessage(`timeout`)),
+			Expected: `(NotFound) and (origin error matches "timeout")`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			if got := testCase.Matcher.String(); got != testCase.Expected {
+				t.Errorf("got %q, expected %q", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+// testV1Error has the method set of awserr.RequestFailure from the AWS SDK for Go v1
+type testV1Error struct {
+	code       string
+	message    string
+	origErr    error
+	statusCode int
+}
+
+func v1Error(code, message string, origErr error, statusCode int) error {
+	return testV1Error{
+		code:       code,
+		message:    message,
+		origErr:    origErr,
+		statusCode: statusCode,
+	}
+}
+
+func (e testV1Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+func (e testV1Error) Code() string {
+	return e.code
+}
+
+func (e testV1Error) Message() string {
+	return e.message
+}
+
+func (e testV1Error) OrigErr() error {
+	return e.origErr
+}
+
+func (e testV1Error) StatusCode() int {
+	return e.statusCode
+}
+
+func (e testV1Error) RequestID() string {
+	return ""
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// withTracing adds a span for each AWS API operation and a child span for each HTTP attempt, including retries.
+func withTracing(tp trace.TracerProvider) func(*middleware.Stack) error {
+	tracer := logging.Tracer(tp)
+
+	return func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(&operationTracer{tracer: tracer}, middleware.After); err != nil {
+			return err
+		}
+		// Added at the end of the Finalize step, so that it runs after the retry middleware for each attempt
+		// and the trace context headers are not signed
+		return stack.Finalize.Add(&attemptTracer{tracer: tracer}, middleware.After)
+	}
+}
+
+type attemptCounterKeyT string
+
+const attemptCounterKey attemptCounterKeyT = "attempt-counter"
+
+type operationTracer struct {
+	tracer trace.Tracer
+}
+
+func (t *operationTracer) ID() string {
+	return "TF_AWS_OperationTracer"
+}
+
+func (t *operationTracer) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	ctx, span := logging.StartOperationSpan(ctx, t.tracer,
+		awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx),
+		operationAttributes(ctx, in),
+	)
+
+	ctx = context.WithValue(ctx, attemptCounterKey, new(int))
+
+	out, metadata, err = next.HandleInitialize(ctx, in)
+
+	logging.EndSpan(span, err)
+
+	return out, metadata, err
+}
+
+type attemptTracer struct {
+	tracer trace.Tracer
+}
+
+func (t *attemptTracer) ID() string {
+	return "TF_AWS_AttemptTracer"
+}
+
+func (t *attemptTracer) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	request, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown request type %T", in.Request)
+	}
+
+	attempt := 1
+	if counter, ok := ctx.Value(attemptCounterKey).(*int); ok {
+		*counter++
+		attempt = *counter
+	}
+
+	ctx, span := logging.StartAttemptSpan(ctx, t.tracer,
+		awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx),
+		attempt, request.Request,
+	)
+
+	out, metadata, err = next.HandleFinalize(ctx, in)
+
+	var resp *http.Response
+	if v, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		resp = v.Response
+	}
+	logging.EndAttemptSpan(span, resp, err)
+
+	return out, metadata, err
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const getCallerIdentityResponse = `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::222222222222:user/Alice</Arn>
+    <UserId>AKIAI44QH8DHBEXAMPLE</UserId>
+    <Account>222222222222</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata>
+    <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+  </ResponseMetadata>
+</GetCallerIdentityResponse>`
+
+func TestTracing(t *testing.T) {
+	var mu sync.Mutex
+	var traceparents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(getCallerIdentityResponse))
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+		APIOptions: []func(*middleware.Stack) error{
+			withTracing(tp),
+		},
+	})
+
+	if _, err := client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	spans := recorder.Ended()
+	if a, e := len(spans), 3; a != e {
+		t.Fatalf("expected %d spans, got %d", e, a)
+	}
+
+	// Attempt spans end before the operation span
+	first, second, operation := spans[0], spans[1], spans[2]
+
+	if a, e := operation.Name(), "STS.GetCallerIdentity"; a != e {
+		t.Errorf("expected operation span name %q, got %q", e, a)
+	}
+	if a, e := operation.SpanKind(), trace.SpanKindInternal; a != e {
+		t.Errorf("expected operation span kind %s, got %s", e, a)
+	}
+	expectSpanAttribute(t, operation, attribute.String("rpc.service", "STS"))
+	expectSpanAttribute(t, operation, attribute.String("rpc.method", "GetCallerIdentity"))
+	expectSpanAttribute(t, operation, attribute.String("aws.region", "us-east-1"))
+
+	for i, attempt := range []sdktrace.ReadOnlySpan{first, second} {
+		if a, e := attempt.Name(), "STS.GetCallerIdentity attempt"; a != e {
+			t.Errorf("expected attempt span name %q, got %q", e, a)
+		}
+		if a, e := attempt.SpanKind(), trace.SpanKindClient; a != e {
+			t.Errorf("expected attempt span kind %s, got %s", e, a)
+		}
+		if a, e := attempt.Parent().SpanID(), operation.SpanContext().SpanID(); a != e {
+			t.Errorf("expected attempt span parent %s, got %s", e, a)
+		}
+		expectSpanAttribute(t, attempt, logging.AttemptKey.Int(i+1))
+
+		expected := "00-" + attempt.SpanContext().TraceID().String() + "-" + attempt.SpanContext().SpanID().String() + "-01"
+		if a := traceparents[i]; a != expected {
+			t.Errorf("attempt %d: expected traceparent %q, got %q", i+1, expected, a)
+		}
+	}
+	expectSpanAttribute(t, first, attribute.Int("http.status_code", http.StatusInternalServerError))
+	expectSpanAttribute(t, second, attribute.Int("http.status_code", http.StatusOK))
+}
+
+func TestGetAwsConfig_tracing(t *testing.T) {
+	servicemocks.InitSessionTestEnv(t)
+
+	ts := servicemocks.MockAwsApiServer("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsGetCallerIdentityValidEndpoint,
+	})
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, _, diags := GetAwsConfig(context.Background(), &Config{
+		AccessKey:      servicemocks.MockStaticAccessKey,
+		SecretKey:      servicemocks.MockStaticSecretKey,
+		Region:         "us-east-1",
+		StsEndpoint:    ts.URL,
+		TracerProvider: tp,
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	if !slices.Contains(names, "STS.GetCallerIdentity") {
+		t.Errorf("expected span %q, got %v", "STS.GetCallerIdentity", names)
+	}
+}
+
+func expectSpanAttribute(t *testing.T, span sdktrace.ReadOnlySpan, expected attribute.KeyValue) {
+	t.Helper()
+
+	for _, attr := range span.Attributes() {
+		if attr.Key == expected.Key {
+			if attr.Value != expected.Value {
+				t.Errorf("span %q: expected attribute %s=%s, got %s", span.Name(), expected.Key, expected.Value.Emit(), attr.Value.Emit())
+			}
+			return
+		}
+	}
+	t.Errorf("span %q: expected attribute %s", span.Name(), expected.Key)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
+)
+
+// addClockSkewCorrection replaces the Signature Version 4 signing handler with one that adds the clock skew observed in
+// AWS API responses to the signing time, matching the clock skew correction for the AWS SDK for Go v2.
+// Service clients add the signing handler after copying the session handlers, so it is replaced on each request.
+func addClockSkewCorrection(handlers *request.Handlers, tracker *clockskew.Tracker) {
+	signer := request.NamedHandler{
+		Name: v4.SignRequestHandler.Name,
+		Fn: func(r *request.Request) {
+			// The first attempt is signed with the time the request was built, and retries with the current time
+			if r.LastSignedAt.IsZero() {
+				r.Time = r.Time.Add(tracker.Offset())
+			}
+			v4.SignSDKRequestWithCurrentTime(r, func() time.Time {
+				return time.Now().Add(tracker.Offset())
+			})
+		},
+	}
+
+	// Build handlers run once per operation, before the first attempt is signed
+	handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_ClockSkewCorrection",
+		Fn: func(r *request.Request) {
+			r.Handlers.Sign.Swap(v4.SignRequestHandler.Name, signer)
+		},
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
+)
+
+func TestClockSkewCorrection(t *testing.T) {
+	const skew = -20 * time.Minute
+
+	now := time.Now()
+	tracker := &clockskew.Tracker{}
+	tracker.Observe(&http.Response{
+		Header: http.Header{"Date": []string{now.Add(skew).UTC().Format(http.TimeFormat)}},
+	}, now, now)
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String("http://127.0.0.1"),
+		Region:      aws.String("us-east-1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addClockSkewCorrection(&sess.Handlers, tracker)
+
+	signingTime := func(r *http.Request) time.Time {
+		t.Helper()
+
+		v, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Fatalf("parsing X-Amz-Date: %s", err)
+		}
+		return v
+	}
+
+	req, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	if err := req.Sign(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := signingTime(req.HTTPRequest), now.Add(skew); a.Sub(e).Abs() > 2*time.Second {
+		t.Errorf("expected signing time %s, got %s", e, a)
+	}
+
+	// Retries are signed again with the current time
+	req.LastSignedAt = now
+	if err := req.Sign(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := signingTime(req.HTTPRequest), now.Add(skew); a.Sub(e).Abs() > 2*time.Second {
+		t.Errorf("expected signing time %s on retry, got %s", e, a)
+	}
+}
@@ -13,6 +13,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 )
 
+// v2CredentialsInvalidator is implemented by (v2)`aws.CredentialsCache`
+type v2CredentialsInvalidator interface {
+	Invalidate()
+}
+
 type v2CredentialsProvider struct {
 	provider awsv2.CredentialsProvider
 
@@ -31,18 +36,27 @@ type v2CredentialsProvider struct {
 // Since the SDK v1 `credentials.Credentials` handles expiry, it has an `Expire` function to explicitly expire credentials. This is
 // used, for example, in the SDK v1 default retry handler to catch an expired credentials error. Because of this, the result of
 // `RetrieveWithContext` cannot be cached in `v2CredentialsProvider`.
-// NOTE: Since the `Expire()` call is not passed up the chain, the (v2)`aws.CredentialsCache` will not have its cache cleared. This
-// may cause problems if a credential is revoked early. If this becomes a problem, every call to `RetrieveWithContext` may need to
-// call `Invalidate()` on the (v2)`aws.CredentialsCache`. In practice, `RetrieveWithContext` is rarely called, so this is not likely
-// to have a significant impact.
+// The `Expire()` call is not passed up the chain, but `credentials.Credentials` only calls `RetrieveWithContext` when its own
+// credentials are missing or the provider reports them as expired. If `RetrieveWithContext` is called while the last retrieved
+// credentials are not expired, they must have been explicitly expired, so the (v2)`aws.CredentialsCache`, or any other provider
+// implementing `Invalidate()`, is invalidated before retrieving. This ensures that revoked or rotated credentials are refreshed.
+// The (v2)`aws.CredentialsCache` is typically shared with the `aws.Config` the session was created from, so the credentials
+// used by AWS SDK for Go v2 clients are refreshed as well.
 //
 // The expiry information is cached in `v2CredentialsProvider` because the SDK v1 model handles expiry separately from the credential
 // information, and otherwise calling `IsExpired()` and `ExpiresAt()` would potentially call the actual credential provider on each call.
 
 func (p *v2CredentialsProvider) RetrieveWithContext(ctx credentials.Context) (credentials.Value, error) {
+	if !p.IsExpired() {
+		if invalidator, ok := p.provider.(v2CredentialsInvalidator); ok {
+			invalidator.Invalidate()
+		}
+		p.v2creds.Store((*awsv2.Credentials)(nil))
+	}
+
 	v2creds, err := p.provider.Retrieve(ctx)
 	if err != nil {
-		return credentials.Value{}, err
+		return credentials.Value{}, convertV2Error(err)
 	}
 	p.v2creds.Store(&v2creds)
 
@@ -243,3 +243,83 @@ func (s *mockAssumeRole) AssumeRole(ctx context.Context, params *stsv2.AssumeRol
 		},
 	}, nil
 }
+
+func TestV2CredentialsProviderExpire_CredentialsCache(t *testing.T) {
+	ctx := test.Context(t)
+
+	stsClientCalls := 0
+	stsClient := &mockAssumeRole{
+		TestInput: func(in *stsv2.AssumeRoleInput) {
+			stsClientCalls++
+		},
+	}
+	v2creds := awsv2.NewCredentialsCache(stscredsv2.NewAssumeRoleProvider(stsClient, "role"))
+	creds := newV2Credentials(v2creds)
+
+	_, err := creds.GetWithContext(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := stsClientCalls, 1; a != e {
+		t.Errorf("STS client calls: expected %d, got %d", e, a)
+	}
+
+	_, err = v2creds.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := stsClientCalls, 1; a != e {
+		t.Errorf("STS client calls: expected v2 credentials cache to be used, got %d calls", a)
+	}
+
+	creds.Expire()
+	if a, e := stsClientCalls, 1; a != e {
+		t.Errorf("STS client calls: did not expect call to STS client, got %d calls", a)
+	}
+
+	_, err = creds.GetWithContext(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := stsClientCalls, 2; a != e {
+		t.Errorf("STS client calls: expected v2 credentials cache to be invalidated, got %d calls", a)
+	}
+
+	_, err = v2creds.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := stsClientCalls, 2; a != e {
+		t.Errorf("STS client calls: expected refreshed v2 credentials cache to be used, got %d calls", a)
+	}
+
+	_, err = creds.GetWithContext(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := stsClientCalls, 2; a != e {
+		t.Errorf("STS client calls: did not expect call to STS client, got %d calls", a)
+	}
+}
+
+func TestV2CredentialsProviderExpire_NotInvalidatable(t *testing.T) {
+	ctx := test.Context(t)
+
+	v2creds := credentialsv2.NewStaticCredentialsProvider("key", "secret", "session")
+	creds := newV2Credentials(v2creds)
+
+	_, err := creds.GetWithContext(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	creds.Expire()
+
+	value, err := creds.GetWithContext(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := value.AccessKeyID, "key"; a != e {
+		t.Errorf("AccessKeyID: expected %q, got %q", e, a)
+	}
+}
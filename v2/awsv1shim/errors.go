@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	awshttpv2 "github.com/aws/aws-sdk-go-v2/aws/transport/http" // nosemgrep: no-sdkv2-imports-in-awsv1shim
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+)
+
+// v2RequestFailure is an `awserr.RequestFailure` translated from an AWS SDK for Go v2 error.
+// The original error is both the `OrigErr()` and reachable using `errors.As`.
+type v2RequestFailure struct {
+	awserr.RequestFailure
+}
+
+func (e v2RequestFailure) Unwrap() error {
+	return e.OrigErr()
+}
+
+// convertV2Error translates AWS SDK for Go v2 API and operation errors into SDK v1 `awserr.RequestFailure`s so that
+// SDK v1 retry handlers and `tfawserr` functions can classify them.
+// Other errors, including those already implementing `awserr.Error`, are returned unchanged.
+func convertV2Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return err
+	}
+
+	var statusCode int
+	var requestID string
+	var respErr *awshttpv2.ResponseError
+	if errors.As(err, &respErr) {
+		statusCode = respErr.HTTPStatusCode()
+		requestID = respErr.ServiceRequestID()
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return v2RequestFailure{
+			RequestFailure: awserr.NewRequestFailure(awserr.New(apiErr.ErrorCode(), apiErr.ErrorMessage(), err), statusCode, requestID),
+		}
+	}
+
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		code, message := operationErrorCode(opErr)
+		return v2RequestFailure{
+			RequestFailure: awserr.NewRequestFailure(awserr.New(code, message, err), statusCode, requestID),
+		}
+	}
+
+	return err
+}
+
+// errCodeUnknown is used for operation errors that the AWS SDK for Go v1 has no error code for
+const errCodeUnknown = "UnknownError"
+
+// operationErrorCode returns the error code and message that the AWS SDK for Go v1 uses for an operation that
+// failed without an API error.
+func operationErrorCode(opErr *smithy.OperationError) (code, message string) {
+	var invalidParams smithy.InvalidParamsError
+	switch {
+	case errors.Is(opErr, context.Canceled), errors.Is(opErr, context.DeadlineExceeded):
+		return request.CanceledErrorCode, "request context canceled"
+	case isSendError(opErr):
+		return request.ErrCodeRequestError, "send request failed"
+	case errors.As(opErr, new(*smithy.SerializationError)):
+		return request.ErrCodeSerialization, "failed to serialize request"
+	case errors.As(opErr, new(*smithy.DeserializationError)):
+		return request.ErrCodeSerialization, "failed to deserialize response"
+	case errors.As(opErr, &invalidParams):
+		return request.InvalidParameterErrCode, fmt.Sprintf("%d validation error(s) found.", invalidParams.Len())
+	default:
+		return errCodeUnknown, opErr.Err.Error()
+	}
+}
+
+// isSendError returns true if the HTTP request could not be sent, or its response could not be read.
+func isSendError(err error) bool {
+	if errors.As(err, new(*smithyhttp.RequestSendError)) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// newNoValidCredentialSourcesError returns a NoValidCredentialSourcesError. The AWS request IDs of a failed API call
+// are appended to the error, as the diagnostic only includes the error.
+func newNoValidCredentialSourcesError(c *awsbase.Config, err error) awsbase.NoValidCredentialSourcesError {
+	return c.NewNoValidCredentialSourcesError(config.WithRequestIDs(err))
+}
@@ -0,0 +1,301 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"                    // nosemgrep: no-sdkv2-imports-in-awsv1shim
+	awshttpv2 "github.com/aws/aws-sdk-go-v2/aws/transport/http" // nosemgrep: no-sdkv2-imports-in-awsv1shim
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/tfawserr"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
+)
+
+func TestConvertV2Error(t *testing.T) {
+	v1Err := awserr.New("TestCode", "TestMessage", nil)
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	testcases := map[string]struct {
+		err                error
+		expectedCode       string
+		expectedMessage    string
+		expectedStatusCode int
+		expectedRequestID  string
+		expectedThrottle   bool
+		expectUnchanged    bool
+	}{
+		"nil": {
+			err:             nil,
+			expectUnchanged: true,
+		},
+		"other error": {
+			err:             errors.New("test"),
+			expectUnchanged: true,
+		},
+		"v1 error": {
+			err:             fmt.Errorf("test: %w", v1Err),
+			expectUnchanged: true,
+		},
+		"API error": {
+			err:             &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"},
+			expectedCode:    "AccessDenied",
+			expectedMessage: "not authorized",
+		},
+		"operation error": {
+			err: v2OperationError(v2ResponseError(&smithy.GenericAPIError{
+				Code:    "AccessDenied",
+				Message: "not authorized",
+			}, http.StatusForbidden)),
+			expectedCode:       "AccessDenied",
+			expectedMessage:    "not authorized",
+			expectedStatusCode: http.StatusForbidden,
+			expectedRequestID:  "01234567-89ab-cdef-0123-456789abcdef",
+		},
+		"throttling operation error": {
+			err: fmt.Errorf("failed to refresh cached credentials, %w", v2OperationError(v2ResponseError(&smithy.GenericAPIError{
+				Code:    "Throttling",
+				Message: "Rate exceeded",
+			}, http.StatusBadRequest))),
+			expectedCode:       "Throttling",
+			expectedMessage:    "Rate exceeded",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedRequestID:  "01234567-89ab-cdef-0123-456789abcdef",
+			expectedThrottle:   true,
+		},
+		"operation error without API error": {
+			err:             v2OperationError(netErr),
+			expectedCode:    request.ErrCodeRequestError,
+			expectedMessage: "send request failed",
+		},
+		"send failure": {
+			err: v2OperationError(&smithyhttp.RequestSendError{
+				Err: &url.Error{Op: "Post", URL: "https://sts.us-east-1.amazonaws.com/", Err: netErr},
+			}),
+			expectedCode:    request.ErrCodeRequestError,
+			expectedMessage: "send request failed",
+		},
+		"cancelled": {
+			err:             v2OperationError(&smithyhttp.RequestSendError{Err: context.Canceled}),
+			expectedCode:    request.CanceledErrorCode,
+			expectedMessage: "request context canceled",
+		},
+		"deadline exceeded": {
+			err:             v2OperationError(fmt.Errorf("failed to get rate limit token: %w", context.DeadlineExceeded)),
+			expectedCode:    request.CanceledErrorCode,
+			expectedMessage: "request context canceled",
+		},
+		"serialization error": {
+			err:             v2OperationError(&smithy.SerializationError{Err: errors.New("invalid input")}),
+			expectedCode:    request.ErrCodeSerialization,
+			expectedMessage: "failed to serialize request",
+		},
+		"deserialization error": {
+			err:             v2OperationError(&smithy.DeserializationError{Err: errors.New("unexpected EOF")}),
+			expectedCode:    request.ErrCodeSerialization,
+			expectedMessage: "failed to deserialize response",
+		},
+		"invalid parameters": {
+			err:             v2OperationError(invalidParamsError()),
+			expectedCode:    request.InvalidParameterErrCode,
+			expectedMessage: "1 validation error(s) found.",
+		},
+		"other operation error": {
+			err:             v2OperationError(errors.New("resolve auth scheme: endpoint not found")),
+			expectedCode:    "UnknownError",
+			expectedMessage: "resolve auth scheme: endpoint not found",
+		},
+	}
+
+	for name, testcase := range testcases {
+		testcase := testcase
+
+		t.Run(name, func(t *testing.T) {
+			err := convertV2Error(testcase.err)
+
+			if testcase.expectUnchanged {
+				if err != testcase.err { //nolint:errorlint // Testing for identity
+					t.Fatalf("expected error to be unchanged, got %#v", err)
+				}
+				return
+			}
+
+			var reqErr awserr.RequestFailure
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("expected awserr.RequestFailure, got %T", err)
+			}
+			if a, e := reqErr.Code(), testcase.expectedCode; a != e {
+				t.Errorf("Code: expected %q, got %q", e, a)
+			}
+			if a, e := reqErr.Message(), testcase.expectedMessage; a != e {
+				t.Errorf("Message: expected %q, got %q", e, a)
+			}
+			if a, e := reqErr.StatusCode(), testcase.expectedStatusCode; a != e {
+				t.Errorf("StatusCode: expected %d, got %d", e, a)
+			}
+			if a, e := reqErr.RequestID(), testcase.expectedRequestID; a != e {
+				t.Errorf("RequestID: expected %q, got %q", e, a)
+			}
+			if reqErr.OrigErr() != testcase.err { //nolint:errorlint // Testing for identity
+				t.Errorf("OrigErr: expected %#v, got %#v", testcase.err, reqErr.OrigErr())
+			}
+			if !errors.Is(err, testcase.err) {
+				t.Error("expected original error to be reachable using errors.Is")
+			}
+			if !tfawserr.ErrCodeEquals(err, testcase.expectedCode) {
+				t.Errorf("expected tfawserr.ErrCodeEquals to match %q", testcase.expectedCode)
+			}
+			if a, e := request.IsErrorThrottle(err), testcase.expectedThrottle; a != e {
+				t.Errorf("IsErrorThrottle: expected %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestConvertV2Error_cancelled(t *testing.T) {
+	err := convertV2Error(v2OperationError(&smithyhttp.RequestSendError{Err: context.Canceled}))
+
+	if request.IsErrorRetryable(err) {
+		t.Error("expected error not to be retryable")
+	}
+}
+
+func TestConvertV2Error_requestError(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	err := convertV2Error(v2OperationError(v2ResponseError(netErr, 0)))
+
+	if !request.IsErrorRetryable(err) {
+		t.Error("expected error to be retryable")
+	}
+
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) {
+		t.Error("expected smithy.OperationError to be reachable using errors.As")
+	}
+	var respErr *awshttpv2.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Error("expected awshttp.ResponseError to be reachable using errors.As")
+	}
+	var target *net.OpError
+	if !errors.As(err, &target) {
+		t.Error("expected net.OpError to be reachable using errors.As")
+	}
+}
+
+func TestV2CredentialsProviderError(t *testing.T) {
+	ctx := test.Context(t)
+
+	v2creds := awsv2.CredentialsProviderFunc(func(context.Context) (awsv2.Credentials, error) {
+		return awsv2.Credentials{}, v2OperationError(v2ResponseError(&smithy.GenericAPIError{
+			Code:    "ExpiredToken",
+			Message: "The security token included in the request is expired",
+		}, http.StatusForbidden))
+	})
+
+	creds := newV2Credentials(v2creds)
+
+	_, err := creds.GetWithContext(ctx)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if !tfawserr.ErrCodeEquals(err, "ExpiredToken") {
+		t.Errorf("expected error code %q, got %s", "ExpiredToken", err)
+	}
+	if !tfawserr.ErrStatusCodeEquals(err, http.StatusForbidden) {
+		t.Errorf("expected status code %d, got %s", http.StatusForbidden, err)
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		t.Error("expected smithy.APIError to be reachable using errors.As")
+	}
+}
+
+func TestNoValidCredentialSourcesError_requestIDs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "4442587FB7D0A2F9")
+		w.Header().Set("X-Amz-Id-2", "vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw=")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:         aws.String(ts.URL),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = s3.New(sess).HeadBucket(&s3.HeadBucketInput{
+		Bucket: aws.String("bucket"),
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	d := newNoValidCredentialSourcesError(&awsbase.Config{}, err)
+
+	for _, e := range []string{
+		"AWS Request ID: 4442587FB7D0A2F9\n",
+		"AWS Extended Request ID: vlR7PnpV2Ce81puvFjW0Ed5ABOmB9onuMZGkJUmQJq8nVQXwk1vI5tS6Iv0zzNJV3PQdRHKdhrw=\n",
+	} {
+		if detail := d.Detail(); !strings.Contains(detail, e) {
+			t.Errorf("expected detail to contain %q, got %q", e, detail)
+		}
+	}
+	// The original error is still available
+	if !tfawserr.ErrStatusCodeEquals(d.Err(), http.StatusForbidden) {
+		t.Errorf("expected wrapped error, got %q", d.Err())
+	}
+}
+
+func invalidParamsError() error {
+	err := smithy.InvalidParamsError{Context: "GetCallerIdentityInput"}
+	err.Add(smithy.NewErrParamRequired("RoleArn"))
+	return err
+}
+
+func v2ResponseError(err error, statusCode int) error {
+	return &awshttpv2.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{
+				Response: &http.Response{
+					StatusCode: statusCode,
+				},
+			},
+			Err: err,
+		},
+		RequestID: "01234567-89ab-cdef-0123-456789abcdef",
+	}
+}
+
+func v2OperationError(err error) error {
+	return &smithy.OperationError{
+		ServiceID:     "STS",
+		OperationName: "AssumeRole",
+		Err:           err,
+	}
+}
@@ -4,19 +4,16 @@
 package awsv1shim
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"net/textproto"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
@@ -39,28 +36,36 @@ func (l debugLogger) Log(args ...interface{}) {
 	}
 	s := strings.Join(tokens, " ")
 	s = strings.ReplaceAll(s, "\r", "") // Works around https://github.com/jen20/teamcity-go-test/pull/2
-	log.Printf("missing_context: %s "+string(logging.AwsSdkKey)+"="+awsSdkGoV1Val, s)
+	logging.MissingContextLogger().Debug(s, string(logging.AwsSdkKey), awsSdkGoV1Val)
 }
 
 func setAWSFields(ctx context.Context, r *request.Request) context.Context {
-	region := aws.StringValue(r.Config.Region)
+	attributes := operationAttributes(r)
+	if signingRegion := r.ClientInfo.SigningRegion; signingRegion != aws.StringValue(r.Config.Region) {
+		attributes = append(attributes, logging.SigningRegion(signingRegion))
+	}
+	attributes = append(attributes, logging.AttemptKey.Int(r.RetryCount+1))
+
+	for _, attribute := range attributes {
+		ctx = tflog.SetField(ctx, string(attribute.Key), attribute.Value.AsInterface())
+	}
 
+	return ctx
+}
+
+// operationAttributes returns the attributes describing an AWS API operation, used for both log fields and spans.
+func operationAttributes(r *request.Request) []attribute.KeyValue {
 	attributes := []attribute.KeyValue{
 		otelaws.SystemAttr(),
 		otelaws.ServiceAttr(r.ClientInfo.ServiceID),
-		otelaws.RegionAttr(region),
+		otelaws.RegionAttr(aws.StringValue(r.Config.Region)),
 		otelaws.OperationAttr(r.Operation.Name),
 		awsSDKv1Attr(),
 	}
-	if signingRegion := r.ClientInfo.SigningRegion; signingRegion != region {
-		attributes = append(attributes, logging.SigningRegion(signingRegion))
-	}
 
-	for _, attribute := range attributes {
-		ctx = tflog.SetField(ctx, string(attribute.Key), attribute.Value.AsInterface())
-	}
+	attributes = append(attributes, logging.ServiceAttributes(r.ClientInfo.ServiceID, r.Params)...)
 
-	return ctx
+	return attributes
 }
 
 const awsSdkGoV1Val = "aws-sdk-go"
@@ -73,40 +78,110 @@ type durationKeyT string
 
 const durationKey durationKeyT = "request-duration"
 
+type harExchangeKeyT string
+
+const harExchangeKey harExchangeKeyT = "har-exchange"
+
+type deferredRequestFieldsKeyT string
+
+// deferredRequestFieldsKey holds the log fields of a request that is logged together with its response
+const deferredRequestFieldsKey deferredRequestFieldsKeyT = "deferred-request-fields"
+
+type requestResponseLogger struct {
+	// metrics is nil unless a MeterProvider is configured
+	metrics *logging.Metrics
+
+	// policy decides which requests and responses are logged. A nil policy logs everything.
+	policy *logging.LogPolicy
+
+	redactor *logging.Redactor
+
+	// har is nil unless HAR export is configured
+	har *logging.HARWriter
+
+	// clockSkew records the clock skew observed in responses. A nil tracker observes nothing.
+	clockSkew *clockskew.Tracker
+
+	// clockSkewThreshold is the clock skew above which a warning is logged. Zero uses the default threshold.
+	clockSkewThreshold time.Duration
+}
+
+// newLogPolicy returns the logging policy for c, matching the AWS SDK for Go v2 logger.
+// SuppressDebugLog turns logging off for all services, including services with an override.
+func newLogPolicy(c *awsbase.Config) (*logging.LogPolicy, error) {
+	policy, err := logging.NewLogPolicy(c.LogVerbosity, c.ServiceLogVerbosity, c.LogSampleRate)
+	if err != nil {
+		return nil, err
+	}
+	if c.SuppressDebugLog {
+		return logging.NewLogPolicy(logging.VerbosityOff, nil, 0)
+	}
+	return policy, nil
+}
+
 // Replaces the built-in logging middleware from https://github.com/aws/aws-sdk-go/blob/main/aws/client/logger.go
 // We want access to the request struct, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v1 without forcing us to manually implement
 // configuration that the SDK handles for us.
-var requestLogger = request.NamedHandler{
-	Name: "TF_AWS_RequestLogger",
-	Fn:   logRequest,
+func (l *requestResponseLogger) requestHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "TF_AWS_RequestLogger",
+		Fn:   l.logRequest,
+	}
 }
 
-func logRequest(r *request.Request) {
+func (l *requestResponseLogger) logRequest(r *request.Request) {
 	ctx := r.Context()
 
-	ctx = setAWSFields(ctx, r)
+	serviceID := r.ClientInfo.ServiceID
 
-	bodySeekable := aws.IsReaderSeekable(r.Body)
+	if l.policy.Verbosity(serviceID) != logging.VerbosityOff {
+		ctx = setAWSFields(ctx, r)
 
-	requestFields, err := logging.DecomposeHTTPRequest(ctx, r.HTTPRequest)
-	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
-		return
-	}
+		bodySeekable := aws.IsReaderSeekable(r.Body)
+
+		requestFields, err := logging.DecomposeHTTPRequest(ctx, r.HTTPRequest)
+		if err != nil {
+			tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
+			return
+		}
+		if !l.policy.LogsBodies(serviceID) {
+			delete(requestFields, "http.request.body")
+		}
+		l.redactor.RedactFields(serviceID, r.Operation.Name, requestFields)
 
-	if !bodySeekable {
-		r.SetReaderBody(aws.ReadSeekCloser(r.HTTPRequest.Body))
+		if !bodySeekable {
+			r.SetReaderBody(aws.ReadSeekCloser(r.HTTPRequest.Body))
+		}
+		// Reset the request body because dumpRequest will re-wrap the
+		// r.HTTPRequest's Body as a NoOpCloser and will not be reset after
+		// read by the HTTP client reader.
+		if err := r.Error; err != nil {
+			tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
+			return
+		}
+
+		if l.policy.DefersRequest(serviceID) {
+			ctx = context.WithValue(ctx, deferredRequestFieldsKey, requestFields)
+		} else {
+			tflog.Debug(ctx, "HTTP Request Sent", requestFields)
+		}
 	}
-	// Reset the request body because dumpRequest will re-wrap the
-	// r.HTTPRequest's Body as a NoOpCloser and will not be reset after
-	// read by the HTTP client reader.
-	if err := r.Error; err != nil {
-		tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
-		return
+
+	if l.har != nil {
+		if x, err := l.har.Begin(r.ClientInfo.ServiceID, r.Operation.Name, r.HTTPRequest); err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("recording HAR entry: %s", err))
+		} else {
+			ctx = context.WithValue(ctx, harExchangeKey, x)
+		}
 	}
 
-	tflog.Debug(ctx, "HTTP Request Sent", requestFields)
+	// The request's context is kept across retries, so the trace is attached once and reset for each attempt
+	if trace := logging.ConnectionTraceFromContext(ctx); trace != nil {
+		trace.Reset()
+	} else {
+		ctx = logging.WithConnectionTrace(ctx, logging.NewConnectionTrace())
+	}
 
 	ctx = context.WithValue(ctx, durationKey, time.Now())
 
@@ -117,43 +192,71 @@ func logRequest(r *request.Request) {
 // We want access to the response struct, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v1 without forcing us to manually implement
 // configuration that the SDK handles for us.
-var responseLogger = request.NamedHandler{
-	Name: "TF_AWS_ResponseLogger",
-	Fn:   logResponse,
+func (l *requestResponseLogger) responseHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "TF_AWS_ResponseLogger",
+		Fn:   l.logResponse,
+	}
 }
 
-func logResponse(r *request.Request) {
+func (l *requestResponseLogger) logResponse(r *request.Request) {
 	ctx := r.Context()
 
 	ctx = setAWSFields(ctx, r)
 
+	serviceID := r.ClientInfo.ServiceID
+	logResponse := l.policy.Verbosity(serviceID) != logging.VerbosityOff &&
+		l.policy.LogsResponse(serviceID, r.Error != nil || r.HTTPResponse == nil || r.HTTPResponse.StatusCode >= http.StatusBadRequest)
+
+	if logResponse {
+		if requestFields, ok := ctx.Value(deferredRequestFieldsKey).(map[string]any); ok {
+			tflog.Debug(ctx, "HTTP Request Sent", requestFields)
+		}
+	}
+
 	if r.HTTPResponse == nil {
-		tflog.Error(ctx, "HTTP response is nil")
+		if logResponse {
+			tflog.Error(ctx, "HTTP response is nil")
+		}
+		l.recordMetrics(r)
+		l.recordHAR(r)
 		return
 	}
 
-	bodyBuffer := bytes.NewBuffer(nil)
+	l.observeClockSkew(ctx, r)
+
+	l.countResponseBytes(r)
+
+	rule := logging.DefaultResponseBodyLoggers.Lookup(serviceID, r.Operation.Name, r.HTTPResponse)
+	logBody := logResponse && l.policy.LogsBodies(serviceID)
 
-	r.HTTPResponse.Body = &teeReaderCloser{
-		Reader: io.TeeReader(r.HTTPResponse.Body, limitWriter(bodyBuffer, responseBufferLen)),
-		Source: r.HTTPResponse.Body,
+	var body *logging.BodyCapture
+	if logBody && !rule.Streaming {
+		body = logging.NewBodyCapture(r.HTTPResponse.Body, responseBufferLen, nil, rule.CaptureOptions()...)
+		r.HTTPResponse.Body = body
 	}
 
+	l.recordHAR(r)
+
 	handlerFn := func(req *request.Request) {
-		ctx := r.Context()
+		l.recordMetrics(r)
 
-		var elapsed time.Duration
-		if start, ok := ctx.Value(durationKey).(time.Time); ok {
-			elapsed = time.Since(start)
+		if !logResponse {
+			return
 		}
 
+		ctx := r.Context()
+
+		elapsed := requestDuration(ctx)
+
 		ctx = setAWSFields(ctx, r)
 
-		responseFields, err := decomposeHTTPResponse(r.HTTPResponse, bodyBuffer, elapsed)
+		responseFields, err := decomposeHTTPResponse(r.HTTPResponse, rule, body, logBody, elapsed, logging.ConnectionTraceFromContext(ctx))
 		if err != nil {
 			tflog.Error(ctx, fmt.Sprintf("decomposing response: %s", err))
 			return
 		}
+		l.redactor.RedactFields(r.ClientInfo.ServiceID, r.Operation.Name, responseFields)
 		tflog.Debug(ctx, "HTTP Response Received", responseFields)
 	}
 
@@ -167,32 +270,100 @@ func logResponse(r *request.Request) {
 	})
 }
 
-type teeReaderCloser struct {
-	// io.Reader will be a tee reader that is used during logging.
-	// This structure will read from a body and write the contents to a logger.
-	io.Reader
-	// Source is used just to close when we are done reading.
-	Source io.ReadCloser
+// observeClockSkew records the clock skew from the Date header of the response, and logs a warning the first time it
+// exceeds the threshold.
+func (l *requestResponseLogger) observeClockSkew(ctx context.Context, r *request.Request) {
+	received := time.Now()
+	sent, ok := ctx.Value(durationKey).(time.Time)
+	if !ok {
+		sent = received
+	}
+
+	if _, ok := l.clockSkew.Observe(r.HTTPResponse, sent, received); !ok {
+		return
+	}
+	if skew, ok := l.clockSkew.Warn(l.clockSkewThreshold); ok {
+		tflog.Warn(ctx, logging.ClockSkewWarning, logging.ClockSkewFields(skew))
+	}
 }
 
-func (reader *teeReaderCloser) Close() error {
-	return reader.Source.Close()
+// recordMetrics records the metrics for the current attempt. When called after unmarshalling, r.Error holds the
+// unmarshalled error, if any. The response size is counted by countResponseBytes.
+func (l *requestResponseLogger) recordMetrics(r *request.Request) {
+	if l.metrics == nil {
+		return
+	}
+
+	attempt := metricsAttempt(r)
+	attempt.Duration = requestDuration(r.Context())
+	attempt.Throttled = r.IsErrorThrottle()
+
+	l.metrics.RecordAttempt(r.Context(), attempt)
+}
+
+// countResponseBytes counts the bytes read from the response body for the current attempt.
+func (l *requestResponseLogger) countResponseBytes(r *request.Request) {
+	l.metrics.CountResponseBytes(r.Context(), metricsAttempt(r), r.HTTPResponse)
+}
+
+func metricsAttempt(r *request.Request) logging.Attempt {
+	attempt := logging.Attempt{
+		ServiceID:    r.ClientInfo.ServiceID,
+		Operation:    r.Operation.Name,
+		Region:       aws.StringValue(r.Config.Region),
+		Number:       r.RetryCount + 1,
+		RequestBytes: r.HTTPRequest.ContentLength,
+	}
+	if r.HTTPResponse != nil {
+		attempt.StatusCode = r.HTTPResponse.StatusCode
+	}
+	return attempt
 }
 
-func decomposeHTTPResponse(resp *http.Response, body io.Reader, elapsed time.Duration) (map[string]any, error) {
+// recordHAR completes the HAR entry for the current attempt. The entry is written once the response body has been read.
+func (l *requestResponseLogger) recordHAR(r *request.Request) {
+	if l.har == nil {
+		return
+	}
+
+	x, ok := r.Context().Value(harExchangeKey).(*logging.HARExchange)
+	if !ok {
+		return
+	}
+
+	l.har.End(r.Context(), x, r.HTTPResponse, r.Error)
+}
+
+func requestDuration(ctx context.Context) time.Duration {
+	if start, ok := ctx.Value(durationKey).(time.Time); ok {
+		return time.Since(start)
+	}
+	return 0
+}
+
+// decomposeHTTPResponse returns the log fields for resp. The body is only included if includeBody is set.
+func decomposeHTTPResponse(resp *http.Response, rule logging.ResponseBodyRule, body *logging.BodyCapture, includeBody bool, elapsed time.Duration, trace *logging.ConnectionTrace) (map[string]any, error) {
 	var attributes []attribute.KeyValue
 
 	attributes = append(attributes, attribute.Int64("http.duration", elapsed.Milliseconds()))
 
+	if trace != nil {
+		attributes = append(attributes, trace.Attributes()...)
+	}
+
 	attributes = append(attributes, httpconv.ClientResponse(resp)...)
 
+	attributes = append(attributes, logging.RequestIDAttributes(resp)...)
+
 	attributes = append(attributes, logging.DecomposeResponseHeaders(resp)...)
 
-	bodyAttribute, err := decomposeResponseBody(body)
-	if err != nil {
-		return nil, err
+	if includeBody {
+		bodyAttribute, err := decomposeResponseBody(resp, rule, body)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, bodyAttribute)
 	}
-	attributes = append(attributes, bodyAttribute)
 
 	result := make(map[string]any, len(attributes))
 	for _, attribute := range attributes {
@@ -202,44 +373,12 @@ func decomposeHTTPResponse(resp *http.Response, body io.Reader, elapsed time.Dur
 	return result, nil
 }
 
-func decomposeResponseBody(bodyReader io.Reader) (kv attribute.KeyValue, err error) {
-	content, err := io.ReadAll(bodyReader)
+// decomposeResponseBody formats the body using the same rules as the AWS SDK for Go v2 logger.
+func decomposeResponseBody(resp *http.Response, rule logging.ResponseBodyRule, body *logging.BodyCapture) (kv attribute.KeyValue, err error) {
+	formatted, err := rule.Format(resp, body)
 	if err != nil {
 		return kv, err
 	}
 
-	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(content)))
-
-	body, err := logging.ReadTruncatedBody(reader, logging.MaxResponseBodyLen)
-	if err != nil {
-		return kv, err
-	}
-
-	return attribute.String("http.response.body", body), nil
-}
-
-func limitWriter(w io.Writer, n int64) io.Writer {
-	return &limitedWriter{w, n}
-}
-
-type limitedWriter struct {
-	W io.Writer // the underlying writer
-	N int64     // max bytes remaining
-}
-
-// Write writes data into the wrapped Writer up to a limit of N bytes
-// Silently stops writing and returns full size of p to allow use with io.TeeReader
-func (w *limitedWriter) Write(p []byte) (int, error) {
-	if w.N <= 0 {
-		return len(p), nil
-	}
-	if int64(len(p)) > w.N {
-		n, err := w.W.Write(p[0:w.N])
-		w.N -= int64(n)
-		return len(p), err
-	} else {
-		n, err := w.W.Write(p)
-		w.N -= int64(n)
-		return n, err
-	}
+	return attribute.String("http.response.body", formatted), nil
 }
@@ -0,0 +1,295 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestOperationAttributes(t *testing.T) {
+	testcases := map[string]struct {
+		serviceID string
+		operation string
+		params    any
+		expected  map[attribute.Key]any
+	}{
+		"Lambda Invoke": {
+			serviceID: lambda.ServiceID,
+			operation: "Invoke",
+			params: &lambda.InvokeInput{
+				FunctionName: aws.String("test-function"),
+				Qualifier:    aws.String("live"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.lambda.function_name": "test-function",
+				"aws.lambda.qualifier":     "live",
+			},
+		},
+		"SNS Publish": {
+			serviceID: sns.ServiceID,
+			operation: "Publish",
+			params: &sns.PublishInput{
+				TopicArn: aws.String("arn:aws:sns:us-east-1:123456789012:test-topic"),
+				Message:  aws.String("test message"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.sns.topic_arn": "arn:aws:sns:us-east-1:123456789012:test-topic",
+			},
+		},
+		"KMS DescribeKey": {
+			serviceID: kms.ServiceID,
+			operation: "DescribeKey",
+			params: &kms.DescribeKeyInput{
+				KeyId: aws.String("alias/test-key"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.kms.key_id": "alias/test-key",
+			},
+		},
+		"Secrets Manager GetSecretValue": {
+			serviceID: secretsmanager.ServiceID,
+			operation: "GetSecretValue",
+			params: &secretsmanager.GetSecretValueInput{
+				SecretId: aws.String("test-secret"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.secretsmanager.secret_id": "test-secret",
+			},
+		},
+		"EC2 DescribeInstances": {
+			serviceID: ec2.ServiceID,
+			operation: "DescribeInstances",
+			params: &ec2.DescribeInstancesInput{
+				InstanceIds: aws.StringSlice([]string{"i-1234567890abcdef0", "i-0fedcba0987654321"}),
+			},
+			expected: map[attribute.Key]any{
+				"aws.ec2.instance_ids": []string{"i-1234567890abcdef0", "i-0fedcba0987654321"},
+			},
+		},
+		"EC2 DescribeVpcs": {
+			serviceID: ec2.ServiceID,
+			operation: "DescribeVpcs",
+			params: &ec2.DescribeVpcsInput{
+				VpcIds: aws.StringSlice([]string{"vpc-1234567890abcdef0"}),
+			},
+			expected: map[attribute.Key]any{
+				"aws.ec2.vpc_ids": []string{"vpc-1234567890abcdef0"},
+			},
+		},
+		"EC2 CreateSubnet": {
+			serviceID: ec2.ServiceID,
+			operation: "CreateSubnet",
+			params: &ec2.CreateSubnetInput{
+				VpcId:     aws.String("vpc-1234567890abcdef0"),
+				CidrBlock: aws.String("10.0.1.0/24"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.ec2.vpc_id": "vpc-1234567890abcdef0",
+			},
+		},
+		"IAM GetRole": {
+			serviceID: iam.ServiceID,
+			operation: "GetRole",
+			params: &iam.GetRoleInput{
+				RoleName: aws.String("test-role"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.iam.role_name": "test-role",
+			},
+		},
+		"IAM AddUserToGroup": {
+			serviceID: iam.ServiceID,
+			operation: "AddUserToGroup",
+			params: &iam.AddUserToGroupInput{
+				UserName:  aws.String("test-user"),
+				GroupName: aws.String("test-group"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.iam.user_name": "test-user",
+			},
+		},
+		"CloudFormation DescribeStacks": {
+			serviceID: cloudformation.ServiceID,
+			operation: "DescribeStacks",
+			params: &cloudformation.DescribeStacksInput{
+				StackName: aws.String("test-stack"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.cloudformation.stack_name": "test-stack",
+			},
+		},
+		"EventBridge PutRule": {
+			serviceID: eventbridge.ServiceID,
+			operation: "PutRule",
+			params: &eventbridge.PutRuleInput{
+				Name:         aws.String("test-rule"),
+				EventBusName: aws.String("test-bus"),
+			},
+			expected: map[attribute.Key]any{
+				"aws.eventbridge.event_bus_name": "test-bus",
+			},
+		},
+		"unset fields": {
+			serviceID: lambda.ServiceID,
+			operation: "ListFunctions",
+			params:    &lambda.ListFunctionsInput{},
+			expected:  map[attribute.Key]any{},
+		},
+		"unsupported service": {
+			serviceID: sts.ServiceID,
+			operation: "GetCallerIdentity",
+			params:    &sts.GetCallerIdentityInput{},
+			expected:  map[attribute.Key]any{},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r := &request.Request{
+				ClientInfo: metadata.ClientInfo{
+					ServiceID: testcase.serviceID,
+				},
+				Config: aws.Config{
+					Region: aws.String("us-east-1"),
+				},
+				Operation: &request.Operation{
+					Name: testcase.operation,
+				},
+				Params: testcase.params,
+			}
+
+			expected := map[attribute.Key]any{
+				"rpc.system":      "aws-api",
+				"rpc.service":     testcase.serviceID,
+				"rpc.method":      testcase.operation,
+				"aws.region":      "us-east-1",
+				logging.AwsSdkKey: awsSdkGoV1Val,
+			}
+			for k, v := range testcase.expected {
+				expected[k] = v
+			}
+
+			actual := make(map[attribute.Key]any)
+			for _, attr := range operationAttributes(r) {
+				actual[attr.Key] = attr.Value.AsInterface()
+			}
+
+			if diff := cmp.Diff(actual, expected); diff != "" {
+				t.Errorf("unexpected attributes: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequestResponseLogger_connectionTraceRetries(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Receiver</Type>
+    <Code>InternalFailure</Code>
+    <Message>Internal failure</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::222222222222:user/Alice</Arn>
+    <UserId>AKIAI44QH8DHBEXAMPLE</UserId>
+    <Account>222222222222</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata>
+    <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+  </ResponseMetadata>
+</GetCallerIdentityResponse>`))
+	}))
+	defer ts.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("us-east-1"),
+		Retryer: client.DefaultRetryer{
+			NumMaxRetries: 1,
+			MinRetryDelay: time.Millisecond,
+			MaxRetryDelay: time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	httpLogger := &requestResponseLogger{
+		redactor: logging.NewRedactor(),
+	}
+	sess.Handlers.Send.PushFrontNamed(httpLogger.requestHandler())
+	sess.Handlers.Send.PushBackNamed(httpLogger.responseHandler())
+
+	var traces []*logging.ConnectionTrace
+	var attempts []map[attribute.Key]attribute.Value
+	sess.Handlers.CompleteAttempt.PushBack(func(r *request.Request) {
+		trace := logging.ConnectionTraceFromContext(r.Context())
+		traces = append(traces, trace)
+
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range trace.Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		attempts = append(attempts, attrs)
+	})
+
+	ctx := tflogtest.RootLogger(context.Background(), io.Discard)
+
+	if _, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if a, e := len(traces), 2; a != e {
+		t.Fatalf("expected %d attempts, got %d", e, a)
+	}
+	// A trace attached for each attempt would also be called back by every later attempt
+	if traces[0] != traces[1] {
+		t.Errorf("expected the connection trace to be attached once, got %p and %p", traces[0], traces[1])
+	}
+	if _, ok := attempts[0][logging.ConnectDurationKey]; !ok {
+		t.Errorf("expected %q attribute for the first attempt", logging.ConnectDurationKey)
+	}
+	if v := attempts[1][logging.ConnectionReusedKey]; !v.AsBool() {
+		t.Errorf("expected the retry to reuse the connection")
+	}
+	if _, ok := attempts[1][logging.ConnectDurationKey]; ok {
+		t.Errorf("did not expect %q attribute for the retry", logging.ConnectDurationKey)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type retryStateKeyT string
+
+const retryStateKey retryStateKeyT = "retry-state"
+
+// retryState holds the outcome of a failed attempt until the retryer has decided whether to retry it.
+type retryState struct {
+	retryCount     int
+	classification logging.RetryClassification
+	errorCode      string
+}
+
+// addRetryLoggingHandlers logs each retry of an AWS API operation, matching the retry logging for the AWS SDK for Go v2.
+// Retries are not logged for services with logging turned off.
+func addRetryLoggingHandlers(handlers *request.Handlers, policy *logging.LogPolicy) {
+	// Retry handlers run after each failed attempt, before the retryer decides whether to retry and sleeps
+	handlers.Retry.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_RetryClassifier",
+		Fn: func(r *request.Request) {
+			var code string
+			if err, ok := r.Error.(awserr.Error); ok {
+				code = err.Code()
+			}
+
+			r.SetContext(context.WithValue(r.Context(), retryStateKey, &retryState{
+				retryCount:     r.RetryCount,
+				classification: logging.ClassifyRetry(code, r.IsErrorThrottle()),
+				errorCode:      code,
+			}))
+		},
+	})
+
+	// The core AfterRetry handler sets the delay, sleeps and increments the retry count if the attempt is retried
+	handlers.AfterRetry.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_RetryLogger",
+		Fn: func(r *request.Request) {
+			state, ok := r.Context().Value(retryStateKey).(*retryState)
+			if !ok || r.RetryCount == state.retryCount {
+				return
+			}
+			if policy.Verbosity(r.ClientInfo.ServiceID) == logging.VerbosityOff {
+				return
+			}
+
+			ctx := setAWSFields(r.Context(), r)
+
+			tflog.Debug(ctx, "Retrying AWS API request", logging.Retry{
+				Attempt:        r.RetryCount + 1,
+				MaxAttempts:    r.MaxRetries() + 1,
+				Delay:          r.RetryDelay,
+				Classification: state.classification,
+				ErrorCode:      state.errorCode,
+			}.Fields())
+		},
+	})
+}
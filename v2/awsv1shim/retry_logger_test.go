@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestRetryLoggingHandlers(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	var mu sync.Mutex
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>Throttling</Code>
+    <Message>Rate exceeded</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`))
+		default:
+			_, _ = w.Write([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::222222222222:user/Alice</Arn>
+    <UserId>AKIAI44QH8DHBEXAMPLE</UserId>
+    <Account>222222222222</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata>
+    <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+  </ResponseMetadata>
+</GetCallerIdentityResponse>`))
+		}
+	}))
+	defer ts.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("us-east-1"),
+		Retryer: client.DefaultRetryer{
+			NumMaxRetries:    3,
+			MinRetryDelay:    delay,
+			MaxRetryDelay:    delay,
+			MinThrottleDelay: delay,
+			MaxThrottleDelay: delay,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addRetryLoggingHandlers(&sess.Handlers, nil)
+
+	var buf bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+	if _, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	lines, err := tflogtest.MultilineJSONDecode(&buf)
+	if err != nil {
+		t.Fatalf("decoding log lines: %s", err)
+	}
+
+	var retries []map[string]any
+	for _, line := range lines {
+		if line["@message"] == "Retrying AWS API request" {
+			retries = append(retries, line)
+		}
+	}
+	if a, e := len(retries), 1; a != e {
+		t.Fatalf("expected %d retry log entry, got %d", e, a)
+	}
+
+	expected := map[string]any{
+		"tf_aws.retry.attempt":            float64(2),
+		"tf_aws.retry.max_attempts":       float64(4),
+		"tf_aws.retry.remaining_attempts": float64(2),
+		"tf_aws.retry.classification":     "throttle",
+		"tf_aws.retry.error_code":         "Throttling",
+		"tf_aws.sdk":                      awsSdkGoV1Val,
+		"rpc.method":                      "GetCallerIdentity",
+	}
+	// The retryer applies jitter to the delay
+	if d, ok := retries[0]["tf_aws.retry.delay"].(float64); !ok || d < 0 || d > float64(delay.Milliseconds()) {
+		t.Errorf("expected delay of at most %d ms, got %v", delay.Milliseconds(), retries[0]["tf_aws.retry.delay"])
+	}
+	for k, e := range expected {
+		if diff := cmp.Diff(e, retries[0][k]); diff != "" {
+			t.Errorf("unexpected %q (+got, -expected): %s", k, diff)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws" // nosemgrep: no-sdkv2-imports-in-awsv1shim
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+// v2Retryer is an AWS SDK for Go v1 `request.Retryer` backed by an AWS SDK for Go v2 retryer, so that both SDKs use
+// the same retry mode, backoff and retry quota.
+//
+// The retry quota and, in adaptive mode, the client-side rate limit are managed using tokens, which are acquired and
+// released by the handlers added by `addV2RetryerHandlers`.
+type v2Retryer struct {
+	retryer awsv2.RetryerV2
+}
+
+var _ request.Retryer = &v2Retryer{}
+
+func newV2Retryer(retryer awsv2.Retryer) *v2Retryer {
+	return &v2Retryer{
+		retryer: netretry.AsRetryerV2(retryer),
+	}
+}
+
+// MaxRetries returns the v2 retryer's maximum attempts, matching the session's MaxRetries.
+func (r *v2Retryer) MaxRetries() int {
+	return r.retryer.MaxAttempts()
+}
+
+// ShouldRetry returns true if the error is retryable by either SDK and a retry token can be acquired.
+func (r *v2Retryer) ShouldRetry(req *request.Request) bool {
+	// If one of the other handlers already set the retry state
+	// we don't want to override it based on the service's state
+	if req.Retryable != nil {
+		return *req.Retryable
+	}
+
+	err := newV1RetryError(req)
+	if !req.IsErrorRetryable() && !req.IsErrorThrottle() && !r.retryer.IsErrorRetryable(err) {
+		return false
+	}
+
+	// The request will not be retried, so no retry token is needed
+	if req.RetryCount >= req.MaxRetries() {
+		return true
+	}
+
+	release, tokenErr := r.retryer.GetRetryToken(req.Context(), err)
+	if tokenErr != nil {
+		logger := logging.RetrieveLogger(req.Context())
+		logger.Warn(req.Context(), "Disabling retries: retry quota exceeded", map[string]any{
+			"error": tokenErr,
+		})
+		return false
+	}
+	if state, ok := req.Context().Value(retryTokensKey).(*retryTokens); ok {
+		state.retry = release
+	}
+
+	return true
+}
+
+// RetryRules returns the v2 retryer's delay before the next attempt.
+func (r *v2Retryer) RetryRules(req *request.Request) time.Duration {
+	delay, err := r.retryer.RetryDelay(req.RetryCount+1, newV1RetryError(req))
+	if err != nil {
+		// Only wrappers abandoning retries return errors, and the v1 retry handlers make that decision
+		return 0
+	}
+	return delay
+}
+
+type retryTokensKeyT string
+
+const retryTokensKey retryTokensKeyT = "retry-tokens"
+
+// retryTokens holds the release functions of the tokens acquired for the current attempt.
+type retryTokens struct {
+	attempt func(error) error
+	retry   func(error) error
+}
+
+// release releases the tokens with the outcome of the attempt
+func (t *retryTokens) release(err error) {
+	if t.attempt != nil {
+		_ = t.attempt(err)
+		t.attempt = nil
+	}
+	if t.retry != nil {
+		_ = t.retry(err)
+		t.retry = nil
+	}
+}
+
+// addV2RetryerHandlers acquires and releases the v2 retryer's attempt and retry tokens for each attempt.
+func addV2RetryerHandlers(handlers *request.Handlers, retryer *v2Retryer) {
+	handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_RetryTokens",
+		Fn: func(r *request.Request) {
+			r.SetContext(context.WithValue(r.Context(), retryTokensKey, &retryTokens{}))
+		},
+	})
+
+	// Sign handlers run before each attempt. In adaptive mode, acquiring the attempt token waits on the client-side rate limit.
+	handlers.Sign.PushFrontNamed(request.NamedHandler{
+		Name: "TF_AWS_AttemptToken",
+		Fn: func(r *request.Request) {
+			state, ok := r.Context().Value(retryTokensKey).(*retryTokens)
+			if !ok || r.IsPresigned() {
+				return
+			}
+
+			release, err := retryer.retryer.GetAttemptToken(r.Context())
+			if err != nil {
+				r.Error = err
+				return
+			}
+			state.attempt = release
+		},
+	})
+
+	handlers.CompleteAttempt.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_ReleaseRetryTokens",
+		Fn:   releaseRetryTokens,
+	})
+
+	// An attempt whose Sign handlers fail, such as the signer failing to retrieve credentials, ends without running
+	// the CompleteAttempt handlers, so its attempt token is released when the request completes.
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_ReleaseRetryTokensOnComplete",
+		Fn:   releaseRetryTokens,
+	})
+}
+
+// releaseRetryTokens releases any tokens still held for the request's current attempt
+func releaseRetryTokens(r *request.Request) {
+	state, ok := r.Context().Value(retryTokensKey).(*retryTokens)
+	if !ok {
+		return
+	}
+
+	var err error
+	if r.Error != nil {
+		err = newV1RetryError(r)
+	}
+	state.release(err)
+}
+
+// v1RetryError exposes an AWS SDK for Go v1 request error using the interfaces examined by the AWS SDK for Go v2 retryer
+type v1RetryError struct {
+	err        error
+	code       string
+	statusCode int
+}
+
+func newV1RetryError(r *request.Request) error {
+	e := v1RetryError{
+		err: r.Error,
+	}
+
+	var awsErr awserr.Error
+	if errors.As(r.Error, &awsErr) {
+		e.code = awsErr.Code()
+	}
+
+	var reqErr awserr.RequestFailure
+	if errors.As(r.Error, &reqErr) {
+		e.statusCode = reqErr.StatusCode()
+	} else if r.HTTPResponse != nil {
+		e.statusCode = r.HTTPResponse.StatusCode
+	}
+
+	return e
+}
+
+func (e v1RetryError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the original error of an awserr.Error, which does not support errors.Unwrap
+func (e v1RetryError) Unwrap() error {
+	if awsErr, ok := e.err.(awserr.Error); ok {
+		return awsErr.OrigErr()
+	}
+	return e.err
+}
+
+func (e v1RetryError) ErrorCode() string {
+	return e.code
+}
+
+func (e v1RetryError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+func (e v1RetryError) CanceledError() bool {
+	return e.code == request.CanceledErrorCode
+}
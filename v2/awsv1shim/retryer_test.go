@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	retryv2 "github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestV1RetryError(t *testing.T) {
+	testcases := map[string]struct {
+		err               error
+		statusCode        int
+		expectedRetryable bool
+		expectedThrottle  bool
+	}{
+		"throttling": {
+			err:               awserr.NewRequestFailure(awserr.New("Throttling", "Rate exceeded", nil), http.StatusBadRequest, ""),
+			expectedRetryable: true,
+			expectedThrottle:  true,
+		},
+		"service unavailable": {
+			err:               awserr.NewRequestFailure(awserr.New("ServiceUnavailable", "", nil), http.StatusServiceUnavailable, ""),
+			expectedRetryable: true,
+		},
+		"status code from response": {
+			err:               awserr.New("InternalError", "", nil),
+			statusCode:        http.StatusInternalServerError,
+			expectedRetryable: true,
+		},
+		"connection reset": {
+			err: awserr.New(request.ErrCodeRequestError, "send request failed", &url.Error{
+				Op:  "Post",
+				URL: "https://sts.amazonaws.com/",
+				Err: syscall.ECONNRESET,
+			}),
+			expectedRetryable: true,
+		},
+		"access denied": {
+			err: awserr.NewRequestFailure(awserr.New("AccessDenied", "", nil), http.StatusForbidden, ""),
+		},
+		"canceled": {
+			err: awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled),
+		},
+	}
+
+	retryer := retryv2.NewStandard()
+
+	for name, testcase := range testcases {
+		testcase := testcase
+
+		t.Run(name, func(t *testing.T) {
+			req := &request.Request{
+				Error: testcase.err,
+			}
+			if testcase.statusCode != 0 {
+				req.HTTPResponse = &http.Response{StatusCode: testcase.statusCode}
+			}
+
+			err := newV1RetryError(req)
+
+			if a, e := retryer.IsErrorRetryable(err), testcase.expectedRetryable; a != e {
+				t.Errorf("IsErrorRetryable: expected %t, got %t", e, a)
+			}
+			if a, e := retryv2.IsErrorThrottles(retryv2.DefaultThrottles).IsErrorThrottle(err).Bool(), testcase.expectedThrottle; a != e {
+				t.Errorf("IsErrorThrottle: expected %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestV2Retryer(t *testing.T) {
+	testcases := map[string]struct {
+		responses             []int
+		retryTokenErr         error
+		expectedErr           bool
+		expectedAttempts      int
+		expectedRetryDelays   []int
+		expectedAttemptErrors []bool
+		expectedRetryReleases []bool
+	}{
+		"success": {
+			responses:             []int{http.StatusOK},
+			expectedAttempts:      1,
+			expectedAttemptErrors: []bool{false},
+		},
+		"retried": {
+			responses:             []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedAttempts:      3,
+			expectedRetryDelays:   []int{1, 2},
+			expectedAttemptErrors: []bool{true, true, false},
+			expectedRetryReleases: []bool{true, false},
+		},
+		"max attempts": {
+			responses:             []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			expectedErr:           true,
+			expectedAttempts:      4,
+			expectedRetryDelays:   []int{1, 2, 3},
+			expectedAttemptErrors: []bool{true, true, true, true},
+			expectedRetryReleases: []bool{true, true, true},
+		},
+		"retry quota exceeded": {
+			responses:             []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryTokenErr:         errors.New("retry quota exceeded"),
+			expectedErr:           true,
+			expectedAttempts:      1,
+			expectedAttemptErrors: []bool{true},
+		},
+	}
+
+	for name, testcase := range testcases {
+		testcase := testcase
+
+		t.Run(name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := testcase.responses[min(requests, len(testcase.responses)-1)]
+				requests++
+
+				w.Header().Set("Content-Type", "text/xml")
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(servicemocks.MockStsGetCallerIdentityValidResponseBody)) //nolint:errcheck
+				}
+			}))
+			defer server.Close()
+
+			fake := &fakeV2Retryer{
+				maxAttempts:   3,
+				retryTokenErr: testcase.retryTokenErr,
+			}
+			retryer := newV2Retryer(fake)
+
+			var delays []time.Duration
+			sess, err := session.NewSession(request.WithRetryer(&aws.Config{
+				Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+				Endpoint:    aws.String(server.URL),
+				Region:      aws.String("us-east-1"),
+				SleepDelay: func(d time.Duration) {
+					delays = append(delays, d)
+				},
+			}, retryer))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			addV2RetryerHandlers(&sess.Handlers, retryer)
+
+			_, err = sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+			if testcase.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if a, e := requests, testcase.expectedAttempts; a != e {
+				t.Errorf("expected %d attempts, got %d", e, a)
+			}
+
+			var expectedDelays []time.Duration
+			for _, attempt := range testcase.expectedRetryDelays {
+				expectedDelays = append(expectedDelays, time.Duration(attempt)*time.Millisecond)
+			}
+			if a, e := delays, expectedDelays; !slices.Equal(a, e) {
+				t.Errorf("expected retry delays %v, got %v", e, a)
+			}
+			if a, e := fake.attemptErrors, testcase.expectedAttemptErrors; !slices.Equal(a, e) {
+				t.Errorf("expected attempt token releases %v, got %v", e, a)
+			}
+			if a, e := fake.retryReleases, testcase.expectedRetryReleases; !slices.Equal(a, e) {
+				t.Errorf("expected retry token releases %v, got %v", e, a)
+			}
+		})
+	}
+}
+
+func TestV2Retryer_signFailure(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	fake := &fakeV2Retryer{
+		maxAttempts: 3,
+	}
+	retryer := newV2Retryer(fake)
+
+	sess, err := session.NewSession(request.WithRetryer(&aws.Config{
+		Credentials: credentials.NewCredentials(failingProvider{}),
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+	}, retryer))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addV2RetryerHandlers(&sess.Handlers, retryer)
+
+	_, err = sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if a, e := requests, 0; a != e {
+		t.Errorf("expected %d requests, got %d", e, a)
+	}
+	if a, e := fake.attemptErrors, []bool{true}; !slices.Equal(a, e) {
+		t.Errorf("expected attempt token releases %v, got %v", e, a)
+	}
+}
+
+// failingProvider is a credentials provider that always fails, so that signing fails
+type failingProvider struct{}
+
+func (failingProvider) Retrieve() (credentials.Value, error) {
+	return credentials.Value{}, errors.New("no credentials")
+}
+
+func (failingProvider) IsExpired() bool {
+	return true
+}
+
+// fakeV2Retryer records the tokens released. The retry delay in milliseconds is the attempt number.
+type fakeV2Retryer struct {
+	maxAttempts   int
+	retryTokenErr error
+
+	// attemptErrors records whether each attempt token was released with an error
+	attemptErrors []bool
+	// retryReleases records whether each retry token was released with an error
+	retryReleases []bool
+}
+
+func (r *fakeV2Retryer) IsErrorRetryable(error) bool {
+	return false
+}
+
+func (r *fakeV2Retryer) MaxAttempts() int {
+	return r.maxAttempts
+}
+
+func (r *fakeV2Retryer) RetryDelay(attempt int, _ error) (time.Duration, error) {
+	return time.Duration(attempt) * time.Millisecond, nil
+}
+
+func (r *fakeV2Retryer) GetRetryToken(context.Context, error) (func(error) error, error) {
+	if r.retryTokenErr != nil {
+		return nil, r.retryTokenErr
+	}
+	return func(err error) error {
+		r.retryReleases = append(r.retryReleases, err != nil)
+		return nil
+	}, nil
+}
+
+func (r *fakeV2Retryer) GetInitialToken() func(error) error {
+	return func(error) error { return nil }
+}
+
+func (r *fakeV2Retryer) GetAttemptToken(context.Context) (func(error) error, error) {
+	return func(err error) error {
+		r.attemptErrors = append(r.attemptErrors, err != nil)
+		return nil
+	}, nil
+}
@@ -10,6 +10,7 @@ import ( // nosemgrep: no-sdkv2-imports-in-awsv1shim
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws" // nosemgrep: no-sdkv2-imports-in-awsv1shim
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -17,7 +18,10 @@ import ( // nosemgrep: no-sdkv2-imports-in-awsv1shim
 	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/tfawserr"
 	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/awsconfig"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/clockskew"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/netretry"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 )
 
@@ -99,23 +103,66 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	sess, err := session.NewSessionWithOptions(*options)
 	if err != nil {
 		if tfawserr.ErrCodeEquals(err, "NoCredentialProviders") {
-			return nil, diags.Append(c.NewNoValidCredentialSourcesError(err))
+			return nil, diags.Append(newNoValidCredentialSourcesError(c, err))
 		}
 		return nil, diags.AddSimpleError(fmt.Errorf("creating AWS session: %w", err))
 	}
 
 	// Set retries after resolving credentials to prevent retries during resolution
 	if retryer := awsC.Retryer(); retryer != nil {
-		sess = sess.Copy(&aws.Config{MaxRetries: aws.Int(retryer.MaxAttempts())})
+		v1Retryer := newV2Retryer(retryer)
+		sess = sess.Copy(request.WithRetryer(&aws.Config{MaxRetries: aws.Int(retryer.MaxAttempts())}, v1Retryer))
+		addV2RetryerHandlers(&sess.Handlers, v1Retryer)
 	}
 
 	SetSessionUserAgent(sess, c.APNInfo, c.UserAgent)
 
 	sess.Handlers.Build.PushBack(userAgentFromContextHandler)
 
-	if !c.SuppressDebugLog {
-		sess.Handlers.Send.PushFrontNamed(requestLogger)
-		sess.Handlers.Send.PushBackNamed(responseLogger)
+	if c.TracerProvider != nil {
+		addTracingHandlers(&sess.Handlers, c.TracerProvider)
+	}
+
+	// Share the clock skew observed by AWS SDK for Go v2 clients created from awsC
+	clockSkew := clockskew.FromConfig(*awsC)
+	if clockSkew == nil {
+		clockSkew = &clockskew.Tracker{}
+	}
+
+	logPolicy, err := newLogPolicy(c)
+	if err != nil {
+		return nil, diags.AddSimpleError(fmt.Errorf("creating log policy: %w", err))
+	}
+
+	if !logPolicy.Disabled() || c.MeterProvider != nil || c.HAR != nil || c.CorrectClockSkew {
+		metrics, err := logging.NewMetrics(c.MeterProvider)
+		if err != nil {
+			return nil, diags.AddSimpleError(fmt.Errorf("creating metrics instruments: %w", err))
+		}
+		var har *logging.HARWriter
+		if c.HAR != nil {
+			har, err = logging.NewHARWriter(c.HAR, c.RedactionRules...)
+			if err != nil {
+				return nil, diags.AddSimpleError(fmt.Errorf("creating HAR writer: %w", err))
+			}
+		}
+		httpLogger := &requestResponseLogger{
+			metrics:            metrics,
+			policy:             logPolicy,
+			redactor:           logging.NewRedactor(c.RedactionRules...),
+			har:                har,
+			clockSkew:          clockSkew,
+			clockSkewThreshold: c.ClockSkewWarningThreshold,
+		}
+		sess.Handlers.Send.PushFrontNamed(httpLogger.requestHandler())
+		sess.Handlers.Send.PushBackNamed(httpLogger.responseHandler())
+		if !logPolicy.Disabled() {
+			addRetryLoggingHandlers(&sess.Handlers, logPolicy)
+		}
+	}
+
+	if c.CorrectClockSkew {
+		addClockSkewCorrection(&sess.Handlers, clockSkew)
 	}
 
 	// Add custom input from ENV to the User-Agent request header
@@ -134,6 +181,10 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	// MaxRetries will override this logic if it has a lower retry threshold.
 	// NOTE: This logic can be fooled by other request errors raising the retry count
 	//       before any networking error occurs
+	// This decision is made per request. To stop connecting to a failing endpoint across requests,
+	// configure CircuitBreakerThreshold.
+	networkErrors := netretry.NewClassifier(c.MaxNetworkErrorRetries, c.NonRetryableNetworkErrors)
+
 	sess.Handlers.Retry.PushBack(func(r *request.Request) {
 		logger := logging.RetrieveLogger(r.Context())
 
@@ -144,23 +195,19 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 			r.Retryable = aws.Bool(false)
 		}
 
-		if r.RetryCount < constants.MaxNetworkRetryCount {
-			return
-		}
-
-		// RequestError: send request failed
-		// caused by: Post https://FQDN/: dial tcp: lookup FQDN: no such host
-		if tfawserr.ErrMessageAndOrigErrContain(r.Error, request.ErrCodeRequestError, "send request failed", "no such host") {
-			logger.Warn(ctx, "Disabling retries after next request due to networking error", map[string]any{
+		// A TLS certificate pinning failure will not succeed on retry
+		if isTLSPinningError(r.Error) {
+			logger.Warn(ctx, "Disabling retries due to TLS certificate pinning failure", map[string]any{
 				"error": r.Error,
 			})
 			r.Retryable = aws.Bool(false)
+			return
 		}
-		// RequestError: send request failed
-		// caused by: Post https://FQDN/: dial tcp IPADDRESS:443: connect: connection refused
-		if tfawserr.ErrMessageAndOrigErrContain(r.Error, request.ErrCodeRequestError, "send request failed", "connection refused") {
+
+		if class, ok := networkErrors.Abandon(r.RetryCount, r.Error); ok {
 			logger.Warn(ctx, "Disabling retries after next request due to networking error", map[string]any{
-				"error": r.Error,
+				"error":                      r.Error,
+				"tf_aws.network_error_class": class,
 			})
 			r.Retryable = aws.Bool(false)
 		}
@@ -169,6 +216,21 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	return sess, nil
 }
 
+// isTLSPinningError returns true if the error, or any original error wrapped by an awserr.Error, is a TLS certificate pinning failure.
+func isTLSPinningError(err error) bool {
+	for err != nil {
+		if _, ok := config.AsPinningError(err); ok {
+			return true
+		}
+		awsErr, ok := err.(awserr.Error)
+		if !ok {
+			return false
+		}
+		err = awsErr.OrigErr()
+	}
+	return false
+}
+
 func convertFIPSEndpointState(value awsv2.FIPSEndpointState) endpoints.FIPSEndpointState {
 	switch value {
 	case awsv2.FIPSEndpointStateEnabled:
@@ -10,10 +10,13 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
 	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/tfawserr"
 	"github.com/hashicorp/aws-sdk-go-base/v2/diag"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
@@ -1440,7 +1444,7 @@ func TestRetryMode(t *testing.T) {
 
 			testCase.Config.SkipCredsValidation = true
 
-			_, awsConfig, err := awsbase.GetAwsConfig(context.Background(), testCase.Config)
+			ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), testCase.Config)
 			if err != nil {
 				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
 			}
@@ -1449,10 +1453,176 @@ func TestRetryMode(t *testing.T) {
 			if a, e := retryMode, testCase.ExpectedRetryMode; a != e {
 				t.Errorf(`expected RetryMode "%s", got: "%s"`, e.String(), a.String())
 			}
+
+			session, diags := GetSession(ctx, &awsConfig, testCase.Config)
+			if diags.HasError() {
+				t.Fatalf("unexpected error from GetSession(): %v", diags)
+			}
+
+			retryer, ok := session.Config.Retryer.(*v2Retryer)
+			if !ok {
+				t.Fatalf("expected session Retryer to be backed by the AWS SDK for Go v2 retryer, got %T", session.Config.Retryer)
+			}
+			if a, e := retryer.MaxRetries(), awsConfig.Retryer().MaxAttempts(); a != e {
+				t.Errorf("expected MaxRetries %d, got %d", e, a)
+			}
+		})
+	}
+}
+
+func TestGetSession_retryer(t *testing.T) {
+	testCases := map[string]struct {
+		RetryMode         awsv2.RetryMode
+		ExpectedRetryMode awsv2.RetryMode
+	}{
+		"default": {
+			ExpectedRetryMode: awsv2.RetryModeStandard,
+		},
+		"standard": {
+			RetryMode:         awsv2.RetryModeStandard,
+			ExpectedRetryMode: awsv2.RetryModeStandard,
+		},
+		"adaptive": {
+			RetryMode:         awsv2.RetryModeAdaptive,
+			ExpectedRetryMode: awsv2.RetryModeAdaptive,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			servicemocks.InitSessionTestEnv(t)
+
+			config := &awsbase.Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+				RetryMode: testCase.RetryMode,
+				Backoff: retryv2.BackoffDelayerFunc(func(attempt int, _ error) (time.Duration, error) {
+					return time.Duration(attempt) * time.Second, nil
+				}),
+				SkipCredsValidation: true,
+			}
+
+			ctx, awsConfig, diags := awsbase.GetAwsConfig(context.Background(), config)
+			if diags.HasError() {
+				t.Fatalf("error in GetAwsConfig(): %v", diags)
+			}
+
+			session, diags := GetSession(ctx, &awsConfig, config)
+			if diags.HasError() {
+				t.Fatalf("unexpected error from GetSession(): %v", diags)
+			}
+
+			retryer, ok := session.Config.Retryer.(*v2Retryer)
+			if !ok {
+				t.Fatalf("expected session Retryer to be backed by the AWS SDK for Go v2 retryer, got %T", session.Config.Retryer)
+			}
+
+			if a, e := retryerMode(t, retryer.retryer), testCase.ExpectedRetryMode; a != e {
+				t.Errorf(`expected session RetryMode "%s", got: "%s"`, e.String(), a.String())
+			}
+
+			req := &request.Request{
+				Error:      awserr.New("Throttling", "Rate exceeded", nil),
+				RetryCount: 1,
+			}
+			expectedDelay, err := awsConfig.Retryer().RetryDelay(2, req.Error)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if a, e := retryer.RetryRules(req), expectedDelay; a != e {
+				t.Errorf("expected retry delay %s, got %s", e, a)
+			}
 		})
 	}
 }
 
+// retryerMode returns the mode of the AWS SDK for Go v2 retryer, unwrapping retryers that embed the retryer they wrap.
+func retryerMode(t *testing.T, retryer awsv2.Retryer) awsv2.RetryMode {
+	t.Helper()
+
+	for {
+		switch retryer.(type) {
+		case *retryv2.AdaptiveMode:
+			return awsv2.RetryModeAdaptive
+		case *retryv2.Standard:
+			return awsv2.RetryModeStandard
+		}
+
+		v := reflect.Indirect(reflect.ValueOf(retryer))
+		if v.Kind() != reflect.Struct || v.NumField() == 0 || !v.Type().Field(0).Anonymous {
+			t.Fatalf("unknown retryer type %T", retryer)
+		}
+		inner, ok := v.Field(0).Interface().(awsv2.Retryer)
+		if !ok {
+			t.Fatalf("unknown retryer type %T", retryer)
+		}
+		retryer = inner
+	}
+}
+
+func TestGetSession_adaptiveRetryMode(t *testing.T) {
+	ctx := test.Context(t)
+
+	servicemocks.InitSessionTestEnv(t)
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>Throttling</Code>
+    <Message>Rate exceeded</Message>
+  </Error>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`))
+	}))
+	defer ts.Close()
+
+	config := &awsbase.Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		MaxRetries:          1,
+		Region:              "us-east-1",
+		RetryMode:           awsv2.RetryModeAdaptive,
+		SkipCredsValidation: true,
+	}
+	ctx, awsConfig, diags := awsbase.GetAwsConfig(ctx, config)
+	if diags.HasError() {
+		t.Fatalf("error in GetAwsConfig(): %v", diags)
+	}
+
+	session, diags := GetSession(ctx, &awsConfig, config)
+	if diags.HasError() {
+		t.Fatalf("unexpected error from GetSession(): %v", diags)
+	}
+
+	iamconn := iam.New(session, &aws.Config{Endpoint: aws.String(ts.URL)})
+
+	_, err := iamconn.GetUserWithContext(ctx, &iam.GetUserInput{})
+	if !tfawserr.ErrCodeEquals(err, "Throttling") {
+		t.Fatalf("expected Throttling error, got %v", err)
+	}
+	sent := requests
+
+	// The throttled responses enable the client-side rate limit, so the next attempt waits for an attempt token
+	// and is canceled before it is sent
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+
+	_, err = iamconn.GetUserWithContext(ctx, &iam.GetUserInput{})
+	if !tfawserr.ErrCodeEquals(err, request.CanceledErrorCode) {
+		t.Errorf("expected %s error, got %v", request.CanceledErrorCode, err)
+	}
+	if a, e := requests, sent; a != e {
+		t.Errorf("expected %d requests, got %d", e, a)
+	}
+}
+
 func TestServiceEndpointTypes(t *testing.T) {
 	testCases := map[string]struct {
 		Config                       *awsbase.Config
@@ -2412,11 +2582,12 @@ func TestSessionRetryHandlers(t *testing.T) {
 	const maxRetries = 25
 
 	testcases := []struct {
-		Description              string
-		RetryCount               int
-		Error                    error
-		ExpectedRetryableValue   bool
-		ExpectRetryToBeAttempted bool
+		Description               string
+		RetryCount                int
+		Error                     error
+		NonRetryableNetworkErrors []awsbase.NetworkErrorClass
+		ExpectedRetryableValue    bool
+		ExpectRetryToBeAttempted  bool
 	}{
 		{
 			Description:              "other error under maxRetries",
@@ -2495,6 +2666,45 @@ func TestSessionRetryHandlers(t *testing.T) {
 			ExpectedRetryableValue:   true,
 			ExpectRetryToBeAttempted: true,
 		},
+		{
+			Description:              "send request i/o timeout failed over MaxNetworkRetryCount not configured by default",
+			RetryCount:               constants.MaxNetworkRetryCount,
+			Error:                    awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}),
+			ExpectedRetryableValue:   true,
+			ExpectRetryToBeAttempted: true,
+		},
+		{
+			Description:               "send request i/o timeout failed over MaxNetworkRetryCount",
+			RetryCount:                constants.MaxNetworkRetryCount,
+			Error:                     awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}),
+			NonRetryableNetworkErrors: []awsbase.NetworkErrorClass{awsbase.NetworkErrorClassTimeout},
+			ExpectedRetryableValue:    false,
+			ExpectRetryToBeAttempted:  false,
+		},
+		{
+			Description:               "send request read i/o timeout failed over MaxNetworkRetryCount",
+			RetryCount:                constants.MaxNetworkRetryCount,
+			Error:                     awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}),
+			NonRetryableNetworkErrors: []awsbase.NetworkErrorClass{awsbase.NetworkErrorClassTimeout},
+			ExpectedRetryableValue:    true,
+			ExpectRetryToBeAttempted:  true,
+		},
+		{
+			Description:               "send request network unreachable failed over MaxNetworkRetryCount",
+			RetryCount:                constants.MaxNetworkRetryCount,
+			Error:                     awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}),
+			NonRetryableNetworkErrors: []awsbase.NetworkErrorClass{awsbase.NetworkErrorClassNetworkUnreachable},
+			ExpectedRetryableValue:    false,
+			ExpectRetryToBeAttempted:  false,
+		},
+		{
+			Description:               "send request no such host failed over MaxNetworkRetryCount not configured",
+			RetryCount:                constants.MaxNetworkRetryCount,
+			Error:                     awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}),
+			NonRetryableNetworkErrors: []awsbase.NetworkErrorClass{awsbase.NetworkErrorClassConnectionRefused},
+			ExpectedRetryableValue:    true,
+			ExpectRetryToBeAttempted:  true,
+		},
 	}
 	for _, testcase := range testcases {
 		testcase := testcase
@@ -2505,10 +2715,11 @@ func TestSessionRetryHandlers(t *testing.T) {
 			servicemocks.InitSessionTestEnv(t)
 
 			config := &awsbase.Config{
-				AccessKey:           servicemocks.MockStaticAccessKey,
-				MaxRetries:          maxRetries,
-				SecretKey:           servicemocks.MockStaticSecretKey,
-				SkipCredsValidation: true,
+				AccessKey:                 servicemocks.MockStaticAccessKey,
+				MaxRetries:                maxRetries,
+				NonRetryableNetworkErrors: testcase.NonRetryableNetworkErrors,
+				SecretKey:                 servicemocks.MockStaticSecretKey,
+				SkipCredsValidation:       true,
 			}
 			ctx, awsConfig, diags := awsbase.GetAwsConfig(ctx, config)
 			if diags.HasError() {
@@ -2527,11 +2738,8 @@ func TestSessionRetryHandlers(t *testing.T) {
 			request.Error = testcase.Error
 			request.SetContext(ctx)
 
-			// Prevent the retryer from using the default retry delay
-			retryer := request.Retryer.(client.DefaultRetryer)
-			retryer.MinRetryDelay = 1 * time.Microsecond
-			retryer.MaxRetryDelay = 1 * time.Microsecond
-			request.Retryer = retryer
+			// Prevent the retryer from sleeping for the retry delay
+			request.Config.SleepDelay = func(time.Duration) {}
 
 			request.Handlers.Retry.Run(request)
 			request.Handlers.AfterRetry.Run(request)
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestSpansKeyT string
+
+const requestSpansKey requestSpansKeyT = "request-spans"
+
+// requestSpans holds the spans of a single AWS API operation while its handlers run.
+type requestSpans struct {
+	ctx       context.Context // the context of the operation span, the parent of each attempt span
+	operation trace.Span
+	attempt   trace.Span
+	attempts  int
+}
+
+// addTracingHandlers adds a span for each AWS API operation and a child span for each HTTP attempt, including retries.
+// The spans match those created for the AWS SDK for Go v2.
+func addTracingHandlers(handlers *request.Handlers, tp trace.TracerProvider) {
+	tracer := logging.Tracer(tp)
+
+	// Validate handlers run once per operation, before any attempt.
+	// Presigned requests are never sent, so they are not traced.
+	handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "TF_AWS_OperationTracer",
+		Fn: func(r *request.Request) {
+			if r.IsPresigned() {
+				return
+			}
+
+			ctx, span := logging.StartOperationSpan(r.Context(), tracer,
+				r.ClientInfo.ServiceID, r.Operation.Name,
+				operationAttributes(r),
+			)
+			spans := &requestSpans{
+				operation: span,
+			}
+			ctx = context.WithValue(ctx, requestSpansKey, spans)
+			spans.ctx = ctx
+			r.SetContext(ctx)
+		},
+	})
+
+	// A retry reuses the headers of the previous attempt, so the trace context headers it sent must be removed
+	// before the request is signed again.
+	handlers.Sign.PushFrontNamed(request.NamedHandler{
+		Name: "TF_AWS_TraceContextRemover",
+		Fn: func(r *request.Request) {
+			if r.HTTPRequest != nil {
+				logging.RemoveTraceContext(r.HTTPRequest.Header)
+			}
+		},
+	})
+
+	// Send handlers run for each attempt, after the Sign handlers, including the signer that service clients add
+	// after the session's handlers, so the trace context headers are not signed.
+	handlers.Send.PushFrontNamed(request.NamedHandler{
+		Name: "TF_AWS_AttemptTracer",
+		Fn: func(r *request.Request) {
+			spans, ok := r.Context().Value(requestSpansKey).(*requestSpans)
+			if !ok {
+				return
+			}
+			spans.endAttempt(r)
+
+			spans.attempts++
+			ctx, span := logging.StartAttemptSpan(spans.ctx, tracer,
+				r.ClientInfo.ServiceID, r.Operation.Name,
+				spans.attempts, r.HTTPRequest,
+			)
+			spans.attempt = span
+			r.SetContext(ctx)
+		},
+	})
+
+	// Retry handlers run after each failed attempt
+	handlers.Retry.PushFrontNamed(request.NamedHandler{
+		Name: "TF_AWS_AttemptTracerEnd",
+		Fn: func(r *request.Request) {
+			if spans, ok := r.Context().Value(requestSpansKey).(*requestSpans); ok {
+				spans.endAttempt(r)
+			}
+		},
+	})
+
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "TF_AWS_OperationTracerEnd",
+		Fn: func(r *request.Request) {
+			if spans, ok := r.Context().Value(requestSpansKey).(*requestSpans); ok {
+				spans.endAttempt(r)
+				logging.EndSpan(spans.operation, r.Error)
+			}
+		},
+	})
+}
+
+func (s *requestSpans) endAttempt(r *request.Request) {
+	if s.attempt == nil {
+		return
+	}
+	logging.EndAttemptSpan(s.attempt, r.HTTPResponse, r.Error)
+	s.attempt = nil
+}
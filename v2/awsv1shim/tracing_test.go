@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingHandlers(t *testing.T) {
+	var authorization, traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		traceparent = r.Header.Get("Traceparent")
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(servicemocks.MockStsGetCallerIdentityValidResponseBody)) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("us-east-1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addTracingHandlers(&sess.Handlers, tp)
+
+	if _, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	spans := recorder.Ended()
+	if a, e := len(spans), 2; a != e {
+		t.Fatalf("expected %d spans, got %d", e, a)
+	}
+	attempt, operation := spans[0], spans[1]
+
+	if a, e := operation.SpanKind(), trace.SpanKindInternal; a != e {
+		t.Errorf("expected operation span kind %s, got %s", e, a)
+	}
+	if a, e := attempt.SpanKind(), trace.SpanKindClient; a != e {
+		t.Errorf("expected attempt span kind %s, got %s", e, a)
+	}
+
+	expected := "00-" + attempt.SpanContext().TraceID().String() + "-" + attempt.SpanContext().SpanID().String() + "-01"
+	if a, e := traceparent, expected; a != e {
+		t.Errorf("expected traceparent %q, got %q", e, a)
+	}
+	if strings.Contains(strings.ToLower(authorization), "traceparent") {
+		t.Errorf("expected traceparent header not to be signed, got Authorization %q", authorization)
+	}
+}
+
+func TestTracingHandlers_retry(t *testing.T) {
+	var attempts int
+	var signatureErrs []error
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		signatureErrs = append(signatureErrs, verifySignature(r))
+
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(servicemocks.MockStsGetCallerIdentityValidResponseBody)) //nolint:errcheck
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(1),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addTracingHandlers(&sess.Handlers, tp)
+
+	if _, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if a, e := attempts, 2; a != e {
+		t.Fatalf("expected %d attempts, got %d", e, a)
+	}
+	for i, err := range signatureErrs {
+		if err != nil {
+			t.Errorf("attempt %d: %s", i+1, err)
+		}
+	}
+
+	if a, e := len(recorder.Ended()), 3; a != e {
+		t.Errorf("expected %d spans, got %d", e, a)
+	}
+}
+
+func TestTracingHandlers_presigned(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Region:      aws.String("us-east-1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addTracingHandlers(&sess.Handlers, tp)
+
+	req, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	if _, err := req.Presign(15 * time.Minute); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if a := len(recorder.Started()); a != 0 {
+		t.Errorf("expected no spans, got %d", a)
+	}
+}
+
+// verifySignature signs a copy of r containing only the headers that the client signed,
+// and checks that the signature matches the one sent.
+func verifySignature(r *http.Request) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	authorization := r.Header.Get("Authorization")
+	_, signedHeaders, ok := strings.Cut(authorization, "SignedHeaders=")
+	if !ok {
+		return fmt.Errorf("no signed headers in Authorization %q", authorization)
+	}
+	signedHeaders, _, _ = strings.Cut(signedHeaders, ",")
+
+	signingTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, header := range strings.Split(signedHeaders, ";") {
+		if header != "host" {
+			req.Header.Set(header, r.Header.Get(header))
+		}
+	}
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials("AKID", "SECRET", ""))
+	if _, err := signer.Sign(req, bytes.NewReader(body), "sts", "us-east-1", signingTime); err != nil {
+		return err
+	}
+
+	if a, e := authorization, req.Header.Get("Authorization"); a != e {
+		return fmt.Errorf("signature does not match:\nexpected %q\ngot      %q", e, a)
+	}
+	return nil
+}